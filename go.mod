@@ -1,7 +1,3 @@
 module github.com/jmervine/gojson-http
 
 go 1.20
-
-require github.com/ChimeraCoder/gojson v1.1.0
-
-require gopkg.in/yaml.v2 v2.2.8 // indirect
@@ -1,20 +1,28 @@
 package main
 
 import (
+	"archive/zip"
 	"flag"
 	"fmt"
+	"go/token"
 	"html/template"
+	"io"
 	"io/ioutil"
+	"encoding/json"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode"
     "log"
 
-	"github.com/ChimeraCoder/gojson"
+	"github.com/jmervine/gojson-http/schema"
 )
 
 var (
@@ -22,12 +30,437 @@ var (
 	Port        int
 	Template    string
 	Tmpl        *template.Template
+	Initialisms string
+	PresetFile  string
+	presets     map[string]Options
 	defaultJson = `{ "example": { "from": { "json": true } } }`
 	mutty       = sync.Mutex{}
 )
 
 type Result struct {
-	Json, Struct string
+	Options
+	Json     string           `json:"-"`
+	Error    string           `json:"error,omitempty"`
+	Files    []File           `json:"files"`
+	Warnings []schema.Warning `json:"warnings,omitempty"`
+	// Diagnostics are compile errors in generated Go files, which are
+	// withheld from Files.
+	Diagnostics []schema.Diagnostic `json:"diagnostics,omitempty"`
+	// Permalink and Curl reproduce the result in the page and the API.
+	Permalink string   `json:"permalink,omitempty"`
+	Curl      string   `json:"curl,omitempty"`
+	Outputs   []Format `json:"-"`
+	Presets   []string `json:"-"`
+}
+
+// Options are the generation settings read from the request.
+type Options struct {
+	Input   string `json:"input,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Flatten bool   `json:"flatten,omitempty"`
+	Example bool   `json:"example,omitempty"`
+	// Naming and Prefix choose how keys that do not start with an ASCII
+	// letter are named in Go.
+	Naming string `json:"naming,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	// Style and Initialisms choose how the words of Go names are spelled.
+	Style       string `json:"style,omitempty"`
+	Initialisms string `json:"initialisms,omitempty"`
+	// Samples reads the input as a sequence of JSON documents, such as JSON
+	// Lines, each one a sample of the same type.
+	Samples bool `json:"samples,omitempty"`
+	// Enums declares string types with constants for string fields taking
+	// at most EnumMax distinct values over at least EnumMin values seen.
+	Enums     bool `json:"enums,omitempty"`
+	EnumMax   int  `json:"enumMax,omitempty"`
+	EnumMin   int  `json:"enumMin,omitempty"`
+	EnumValid bool `json:"enumValid,omitempty"`
+	// Unions splits arrays of objects told apart by a key such as "type"
+	// into a Go interface with a struct per value. Discriminator names the
+	// key rather than guessing it, and implies Unions.
+	Unions        bool   `json:"unions,omitempty"`
+	Discriminator string `json:"discriminator,omitempty"`
+	// Mixed is the Go type of values seen with conflicting types: "any"
+	// (interface{}, the default), "raw" (json.RawMessage) or "union" (a
+	// struct with a field per type).
+	Mixed string `json:"mixed,omitempty"`
+	// Bases moves fields that at least BaseMin structs declare alike into
+	// a base struct they embed.
+	Bases   bool `json:"bases,omitempty"`
+	BaseMin int  `json:"baseMin,omitempty"`
+	// Int32 narrows integers that always fit 32 bits to int32.
+	Int32 bool `json:"int32,omitempty"`
+	// Quoted reads strings that always hold numbers, or always booleans,
+	// as those types with the ",string" tag option.
+	Quoted bool `json:"quoted,omitempty"`
+	// Formats tags strings holding UUIDs, URIs, email or IP addresses and
+	// base64 data with their format; FormatTypes maps formats to Go types,
+	// such as "uuid=github.com/google/uuid.UUID".
+	Formats     bool   `json:"formats,omitempty"`
+	FormatTypes string `json:"formatTypes,omitempty"`
+	// Extra gives Go structs a map keeping the keys they do not declare.
+	Extra bool `json:"extra,omitempty"`
+	// FastJSON adds methods reading and writing Go types without
+	// reflection, and a benchmark comparing them with encoding/json.
+	FastJSON bool `json:"fastJson,omitempty"`
+	// Tests adds a Go test checking the input survives decoding and
+	// encoding, and a fuzz target seeded with it.
+	Tests bool `json:"tests,omitempty"`
+	// Validate checks Go values against the constraints the samples showed:
+	// "tags" (validate struct tags), "method" (Validate methods) or "both".
+	Validate string `json:"validate,omitempty"`
+	// Select picks the part of the input to generate from, by JSONPath or
+	// JSON Pointer. Envelope keeps the objects enclosing it.
+	Select   string `json:"select,omitempty"`
+	Envelope bool   `json:"envelope,omitempty"`
+	// Overrides is a JSON document of per-path overrides, see
+	// schema.Overrides.
+	Overrides string `json:"overrides,omitempty"`
+	// Preset names a server-configured set of options that fills in any
+	// the request leaves empty.
+	Preset string `json:"preset,omitempty"`
+}
+
+// File is one generated output.
+type File struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Format is an output language offered by the form.
+type Format struct {
+	Name, Label string
+}
+
+var formats = []Format{
+	{"go", "Go struct"},
+	{"python-dataclass", "Python dataclasses"},
+	{"python-pydantic", "Python pydantic v2 models"},
+	{"sql-postgres", "Postgres tables"},
+	{"sql-sqlite", "SQLite tables"},
+	{"graphql", "GraphQL SDL"},
+	{"avro", "Avro schema"},
+}
+
+func readOptions(r *http.Request) Options {
+	o := Options{
+		Input:   r.FormValue("input"),
+		Format:  r.FormValue("format"),
+		Flatten: r.FormValue("flatten") != "",
+		Example: r.FormValue("example") != "",
+		Naming:  r.FormValue("naming"),
+		Prefix:  r.FormValue("prefix"),
+
+		Style:       r.FormValue("style"),
+		Initialisms: r.FormValue("initialisms"),
+		Overrides:   r.FormValue("overrides"),
+		Preset:      r.FormValue("preset"),
+
+		Select:   r.FormValue("select"),
+		Envelope: r.FormValue("envelope") != "",
+
+		Samples:   r.FormValue("samples") != "",
+		Enums:     r.FormValue("enums") != "",
+		EnumMax:   formInt(r, "enum-max"),
+		EnumMin:   formInt(r, "enum-min"),
+		EnumValid: r.FormValue("enum-valid") != "",
+
+		Unions:        r.FormValue("unions") != "",
+		Discriminator: r.FormValue("discriminator"),
+		Mixed:         r.FormValue("mixed"),
+
+		Bases:   r.FormValue("bases") != "",
+		BaseMin: formInt(r, "base-min"),
+		Int32:   r.FormValue("int32") != "",
+		Quoted:  r.FormValue("quoted") != "",
+
+		Formats:     r.FormValue("formats") != "",
+		FormatTypes: r.FormValue("format-types"),
+		Extra:       r.FormValue("extra") != "",
+		FastJSON:    r.FormValue("fast-json") != "",
+		Tests:       r.FormValue("tests") != "",
+		Validate:    r.FormValue("validate"),
+	}
+	if p, ok := lookupPreset(o.Preset); ok {
+		o = o.withDefaults(p)
+	}
+	return o
+}
+
+// formInt reads an optional count, -1 when it is not a number.
+func formInt(r *http.Request, key string) int {
+	s := r.FormValue(key)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// withDefaults fills in the options o leaves empty from p.
+func (o Options) withDefaults(p Options) Options {
+	for _, f := range []struct{ v, def *string }{
+		{&o.Input, &p.Input}, {&o.Format, &p.Format}, {&o.Naming, &p.Naming},
+		{&o.Prefix, &p.Prefix}, {&o.Style, &p.Style}, {&o.Initialisms, &p.Initialisms},
+		{&o.Overrides, &p.Overrides}, {&o.Select, &p.Select}, {&o.Discriminator, &p.Discriminator},
+		{&o.Mixed, &p.Mixed}, {&o.FormatTypes, &p.FormatTypes}, {&o.Validate, &p.Validate},
+	} {
+		if *f.v == "" {
+			*f.v = *f.def
+		}
+	}
+	o.Flatten = o.Flatten || p.Flatten
+	o.Example = o.Example || p.Example
+	o.Envelope = o.Envelope || p.Envelope
+	o.Samples = o.Samples || p.Samples
+	o.Enums = o.Enums || p.Enums
+	o.EnumValid = o.EnumValid || p.EnumValid
+	o.Unions = o.Unions || p.Unions
+	o.Bases = o.Bases || p.Bases
+	o.Int32 = o.Int32 || p.Int32
+	o.Quoted = o.Quoted || p.Quoted
+	o.Formats = o.Formats || p.Formats
+	o.Extra = o.Extra || p.Extra
+	o.FastJSON = o.FastJSON || p.FastJSON
+	o.Tests = o.Tests || p.Tests
+	if o.BaseMin == 0 {
+		o.BaseMin = p.BaseMin
+	}
+	if o.EnumMax == 0 {
+		o.EnumMax = p.EnumMax
+	}
+	if o.EnumMin == 0 {
+		o.EnumMin = p.EnumMin
+	}
+	return o
+}
+
+func lookupPreset(name string) (Options, bool) {
+	mutty.Lock()
+	defer mutty.Unlock()
+	p, ok := presets[name]
+	return p, ok
+}
+
+func presetNames() []string {
+	mutty.Lock()
+	defer mutty.Unlock()
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadPresets reads a JSON object mapping preset names to options, spelled
+// as in the /api response.
+func loadPresets(file string) (map[string]Options, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var p map[string]Options
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return p, nil
+}
+
+// Values encodes o as query parameters understood by readOptions.
+func (o Options) Values() url.Values {
+	v := url.Values{}
+	if o.Input != "" {
+		v.Set("input", o.Input)
+	}
+	if o.Format != "" {
+		v.Set("format", o.Format)
+	}
+	if o.Flatten {
+		v.Set("flatten", "on")
+	}
+	if o.Example {
+		v.Set("example", "on")
+	}
+	if o.Naming != "" {
+		v.Set("naming", o.Naming)
+	}
+	if o.Prefix != "" {
+		v.Set("prefix", o.Prefix)
+	}
+	if o.Style != "" {
+		v.Set("style", o.Style)
+	}
+	if o.Initialisms != "" {
+		v.Set("initialisms", o.Initialisms)
+	}
+	if o.Samples {
+		v.Set("samples", "on")
+	}
+	if o.Enums {
+		v.Set("enums", "on")
+	}
+	if o.EnumMax != 0 {
+		v.Set("enum-max", strconv.Itoa(o.EnumMax))
+	}
+	if o.EnumMin != 0 {
+		v.Set("enum-min", strconv.Itoa(o.EnumMin))
+	}
+	if o.EnumValid {
+		v.Set("enum-valid", "on")
+	}
+	if o.Unions {
+		v.Set("unions", "on")
+	}
+	if o.Discriminator != "" {
+		v.Set("discriminator", o.Discriminator)
+	}
+	if o.Mixed != "" {
+		v.Set("mixed", o.Mixed)
+	}
+	if o.Bases {
+		v.Set("bases", "on")
+	}
+	if o.BaseMin != 0 {
+		v.Set("base-min", strconv.Itoa(o.BaseMin))
+	}
+	if o.Int32 {
+		v.Set("int32", "on")
+	}
+	if o.Quoted {
+		v.Set("quoted", "on")
+	}
+	if o.Formats {
+		v.Set("formats", "on")
+	}
+	if o.FormatTypes != "" {
+		v.Set("format-types", o.FormatTypes)
+	}
+	if o.Extra {
+		v.Set("extra", "on")
+	}
+	if o.FastJSON {
+		v.Set("fast-json", "on")
+	}
+	if o.Tests {
+		v.Set("tests", "on")
+	}
+	if o.Validate != "" {
+		v.Set("validate", o.Validate)
+	}
+	if o.Select != "" {
+		v.Set("select", o.Select)
+	}
+	if o.Envelope {
+		v.Set("envelope", "on")
+	}
+	if o.Overrides != "" {
+		v.Set("overrides", o.Overrides)
+	}
+	if o.Preset != "" {
+		v.Set("preset", o.Preset)
+	}
+	return v
+}
+
+// mixed validates the Go type chosen for values of conflicting types.
+func (o Options) mixed() (schema.MixedMode, error) {
+	if o.Mixed == "" {
+		return schema.MixedAny, nil
+	}
+	m, ok := schema.ParseMixedMode(o.Mixed)
+	if !ok {
+		return m, fmt.Errorf("unknown mixed type %q", o.Mixed)
+	}
+	return m, nil
+}
+
+// validate validates how Go checks values against the constraints seen.
+func (o Options) validate() (schema.ValidateMode, error) {
+	if o.Validate == "" {
+		return schema.ValidateNone, nil
+	}
+	m, ok := schema.ParseValidateMode(o.Validate)
+	if !ok {
+		return m, fmt.Errorf("unknown validate mode %q", o.Validate)
+	}
+	return m, nil
+}
+
+// formatTypes validates the Go types of string formats, nil unless format
+// detection is on.
+func (o Options) formatTypes() (schema.FormatTypes, error) {
+	if !o.Formats {
+		return nil, nil
+	}
+	return schema.ParseFormatTypes(o.FormatTypes)
+}
+
+// baseFields validates the base struct options, returning the fewest fields
+// a base struct holds, 3 by default, or 0 when they are off.
+func (o Options) baseFields() (int, error) {
+	switch {
+	case !o.Bases:
+		return 0, nil
+	case o.BaseMin < 0:
+		return 0, fmt.Errorf("base-min must be a positive number")
+	case o.BaseMin > 0:
+		return o.BaseMin, nil
+	}
+	return 3, nil
+}
+
+// enums validates the enum detection options, filling in default thresholds.
+func (o Options) enums() (schema.EnumOptions, error) {
+	if !o.Enums {
+		return schema.EnumOptions{}, nil
+	}
+	e := schema.EnumOptions{MaxValues: 10, MinCount: 3, Valid: o.EnumValid}
+	if o.EnumMax < 0 || o.EnumMin < 0 {
+		return e, fmt.Errorf("enum-max and enum-min must be positive numbers")
+	}
+	if o.EnumMax > 0 {
+		e.MaxValues = o.EnumMax
+	}
+	if o.EnumMin > 0 {
+		e.MinCount = o.EnumMin
+	}
+	return e, nil
+}
+
+// naming validates the Go naming options.
+func (o Options) naming() (schema.Naming, error) {
+	var n schema.Naming
+	if o.Naming != "" {
+		var ok bool
+		if n.Strategy, ok = schema.ParseNameStrategy(o.Naming); !ok {
+			return n, fmt.Errorf("unknown naming strategy %q", o.Naming)
+		}
+	}
+	if o.Prefix != "" && !(token.IsIdentifier(o.Prefix) && token.IsExported(o.Prefix)) {
+		return n, fmt.Errorf("name prefix %q is not an exported Go identifier", o.Prefix)
+	}
+	n.Prefix = o.Prefix
+	if o.Style != "" {
+		var ok bool
+		if n.Style, ok = schema.ParseNameStyle(o.Style); !ok {
+			return n, fmt.Errorf("unknown naming style %q", o.Style)
+		}
+	}
+	// the server's initialisms come first so a request can respell them
+	list := strings.FieldsFunc(o.Initialisms+","+Initialisms, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, s := range list {
+		if !token.IsIdentifier(s) || !token.IsExported(s) {
+			return n, fmt.Errorf("initialism %q is not an exported Go identifier", s)
+		}
+		n.Initialisms = append(n.Initialisms, s)
+	}
+	return n, nil
 }
 
 type Handler struct{}
@@ -51,7 +484,10 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
         r.Method, r.URL.Path, r.Header["User-Agent"], time.Since(begin))
 
 	res := Result{
-		Json: defaultJson,
+		Options: readOptions(r),
+		Json:    defaultJson,
+		Outputs: formats,
+		Presets: presetNames(),
 	}
 
 	if strings.HasSuffix(r.URL.Path, "json") {
@@ -67,6 +503,8 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		src = r.URL.Query().Get("src")
 		if src != "" {
 			res.Json = src
+		} else if val := r.URL.Query().Get("json"); val != "" {
+			res.Json = val
 		}
 	}
 
@@ -74,7 +512,9 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		// redirect wth to src param, if res.Json is path, but src path doesn't exist
 		if src == "" {
-			http.Redirect(w, r, r.URL.Path+"?src="+strings.TrimSpace(res.Json), 301)
+			query := res.Options.Values()
+			query.Set("src", strings.TrimSpace(res.Json))
+			http.Redirect(w, r, r.URL.Path+"?"+query.Encode(), 301)
 			return
 		}
 
@@ -84,33 +524,389 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
             log.Printf("at=ServeHTTP method=%s path=%s user-agent=%s took=%v",
                 r.Method, r.URL.Path, r.Header["User-Agent"], time.Since(begin))
             log.Printf("at=ServeHTTP error=%v", err)
-			res.Struct = fmt.Sprintf("JSON Parse Error: %v\n", err)
-			Tmpl.Execute(w, nil)
-			return
+			res.Error = fmt.Sprintf("JSON Fetch Error: %v\n", err)
+		} else {
+			read, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+            log.Printf("at=ServeHTTP method=%s path=%s user-agent=%s took=%v",
+                r.Method, r.URL.Path, r.Header["User-Agent"], time.Since(begin))
+            log.Printf("at=ServeHTTP error=%v", err)
+				res.Error = fmt.Sprintf("JSON Fetch Error: %v\n", err)
+			}
+			res.Json = string(read)
 		}
+	}
 
-		read, err := ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
+	// a failed fetch is answered below like a failed generation
+	if res.Error == "" {
+		if e := generate(&res); e != nil {
             log.Printf("at=ServeHTTP method=%s path=%s user-agent=%s took=%v",
                 r.Method, r.URL.Path, r.Header["User-Agent"], time.Since(begin))
-            log.Printf("at=ServeHTTP error=%v", err)
-			res.Struct = fmt.Sprintf("JSON Fetch Error: %v\n", err)
+            log.Printf("at=ServeHTTP error=%v", e)
+			res.Error = fmt.Sprintf("JSON Parse Error: %v\n", e)
 		}
-		res.Json = string(read)
 	}
+	res.Permalink, res.Curl = links(r, res, src)
 
-	if out, e := gojson.Generate(strings.NewReader(res.Json), gojson.ParseJson, "MyJsonName", "main", []string{"json"}, false, true); e == nil {
-		res.Struct = string(out)
-	} else {
-        log.Printf("at=ServeHTTP method=%s path=%s user-agent=%s took=%v",
-            r.Method, r.URL.Path, r.Header["User-Agent"], time.Since(begin))
-        log.Printf("at=ServeHTTP error=%v", e)
-		res.Struct = fmt.Sprintf("JSON Parse Error: %v\n", e)
+	// the API answers with the result itself rather than the page
+	if r.URL.Path == "/api" {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case res.Error != "":
+			w.WriteHeader(http.StatusBadRequest)
+		case len(res.Diagnostics) > 0:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	// a download answers with the files in an archive, unless there is an
+	// error to show
+	if r.URL.Path == "/download" && res.Error == "" && len(res.Diagnostics) == 0 {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="types.zip"`)
+		if err := writeZip(w, res.Files); err != nil {
+			log.Printf("at=ServeHTTP error=%v", err)
+		}
+		return
 	}
 	Tmpl.Execute(w, res)
 }
 
+// writeZip writes files to w as a zip archive.
+func writeZip(w io.Writer, files []File) error {
+	z := zip.NewWriter(w)
+	now := time.Now()
+	for _, f := range files {
+		fw, err := z.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.Content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// maxPermalink caps the length of permalinks carrying the input itself.
+const maxPermalink = 8 << 10
+
+// links returns a permalink to the page generating res and a curl command
+// asking the API for the same result. Input fetched from src is linked by
+// that URL; other input is carried in the permalink when it is short enough,
+// and read from input.json by the curl command.
+func links(r *http.Request, res Result, src string) (permalink, curl string) {
+	base := "http://" + r.Host
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		base = "https://" + r.Host
+	}
+
+	query := res.Options.Values()
+	args := []string{"curl -s " + shellQuote(base+"/api")}
+	if src != "" {
+		query.Set("src", src)
+		args[0] = "curl -sG " + shellQuote(base+"/api")
+	} else {
+		args = append(args, "--data-urlencode json@input.json")
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--data-urlencode "+shellQuote(k+"="+query.Get(k)))
+	}
+	curl = strings.Join(args, " \\\n    ")
+
+	if src == "" {
+		query.Set("json", res.Json)
+	}
+	if link := base + "/?" + query.Encode(); len(link) <= maxPermalink {
+		permalink = link
+	}
+	return permalink, curl
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// generate converts res.Json to source files in the requested format,
+// defaulting to Go, and collects warnings about the output.
+func generate(res *Result) error {
+	opts := res.Options
+	if _, ok := lookupPreset(opts.Preset); opts.Preset != "" && !ok {
+		return fmt.Errorf("unknown preset %q", opts.Preset)
+	}
+	if opts.Input == "avro" {
+		return generateFromAvro(res)
+	}
+	var docs []interface{}
+	var err error
+	if opts.Samples {
+		docs, err = parseSamples(res.Json)
+	} else {
+		var data interface{}
+		data, err = parseJSON(res.Json)
+		docs = []interface{}{data}
+	}
+	if err != nil {
+		return err
+	}
+	samples := docs
+	if opts.Select != "" {
+		samples = nil
+		for _, doc := range docs {
+			selected, err := schema.Select(doc, opts.Select, opts.Envelope)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, selected...)
+		}
+		if len(samples) == 0 {
+			return fmt.Errorf("select: %s matches nothing", opts.Select)
+		}
+	}
+	t := schema.Infer("MyJsonName", samples...)
+	if (opts.Unions || opts.Discriminator != "") && (opts.Format == "" || opts.Format == "go") {
+		res.Warnings = schema.DetectUnions(t, opts.Discriminator, samples...)
+	}
+	if opts.Quoted && (opts.Format == "" || opts.Format == "go") {
+		res.Warnings = append(res.Warnings, schema.QuotedScalars(t)...)
+	}
+	if opts.Int32 {
+		schema.NarrowInts(t)
+	}
+	if opts.Formats {
+		schema.StringFormats(t)
+	}
+	naming, err := opts.naming()
+	if err != nil {
+		return err
+	}
+	overrides, err := schema.ParseOverrides(opts.Overrides)
+	if err != nil {
+		return err
+	}
+	res.Warnings = append(res.Warnings, overrides.Apply(t)...)
+	if opts.Format != "" && opts.Format != "go" {
+		// Go words them after the type it gives them, below
+		res.Warnings = append(res.Warnings, schema.Conflicts(t)...)
+	}
+	res.Warnings = append(res.Warnings, schema.Precision(t)...)
+	res.Warnings = append(res.Warnings, schema.Empty(t)...)
+
+	switch opts.Format {
+	case "", "go":
+		goOpts := schema.GoOptions{Tags: []string{"json"}, Naming: naming, Extra: opts.Extra, FastJSON: opts.FastJSON, Tests: opts.Tests}
+		if goOpts.Enums, err = opts.enums(); err != nil {
+			return err
+		}
+		if goOpts.Mixed, err = opts.mixed(); err != nil {
+			return err
+		}
+		if goOpts.BaseFields, err = opts.baseFields(); err != nil {
+			return err
+		}
+		if goOpts.Validate, err = opts.validate(); err != nil {
+			return err
+		}
+		if goOpts.FormatTypes, err = opts.formatTypes(); err != nil {
+			return err
+		}
+		if opts.Example {
+			goOpts.Example = samples[0]
+		}
+		res.Warnings = append(res.Warnings, schema.GoConflicts(t, goOpts)...)
+		out, err := schema.Go(t, goOpts)
+		if out == nil {
+			return err
+		}
+		res.Files = []File{{"types.go", string(out)}}
+		if opts.FastJSON && err == nil {
+			err = res.addFastJSON(t, goOpts)
+		}
+		if (opts.FastJSON || opts.Tests) && err == nil {
+			var test []byte
+			test, err = schema.GoTest(t, goOpts, sampleText(res, samples))
+			res.Files = append(res.Files, File{"types_test.go", string(test)})
+		}
+		if !res.check(t, goOpts) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res.Warnings = append(res.Warnings, schema.GoNaming(t, goOpts)...)
+		res.Warnings = append(res.Warnings, schema.GoValidation(t, goOpts)...)
+		res.Warnings = append(res.Warnings, schema.Verify(t, goOpts, samples...)...)
+	case "python-dataclass":
+		res.Files = []File{{"models.py", string(schema.Python(t, schema.Dataclass))}}
+	case "python-pydantic":
+		res.Files = []File{{"models.py", string(schema.Python(t, schema.Pydantic))}}
+	case "sql-postgres", "sql-sqlite":
+		sqlOpts := schema.SQLOptions{Flatten: opts.Flatten, Naming: naming}
+		if opts.Format == "sql-sqlite" {
+			sqlOpts.Dialect = schema.SQLite
+		}
+		ddl, src, err := schema.SQL(t, sqlOpts)
+		if err != nil {
+			return err
+		}
+		res.Files = []File{{"schema.sql", string(ddl)}, {"rows.go", string(src)}}
+		res.check(nil, schema.GoOptions{})
+	case "graphql":
+		sdl, err := schema.GraphQL(t)
+		if err != nil {
+			return err
+		}
+		res.Files = []File{{"schema.graphql", string(sdl)}}
+	case "avro":
+		avsc, err := schema.Avro(t)
+		if err != nil {
+			return err
+		}
+		res.Files = []File{{"schema.avsc", string(avsc)}}
+	default:
+		return fmt.Errorf("unknown format %q", opts.Format)
+	}
+	return nil
+}
+
+// addFastJSON adds the files declaring methods that read and write the Go
+// types of t without reflection. Files that do not format are added
+// unformatted, along with the error.
+func (res *Result) addFastJSON(t *schema.Type, opts schema.GoOptions) error {
+	fast, slow, err := schema.GoJSON(t, opts)
+	res.Files = append(res.Files, File{"types_json.go", string(fast)})
+	if slow != nil {
+		res.Files = append(res.Files, File{"types_reflect.go", string(slow)})
+	}
+	return err
+}
+
+// sampleText returns the JSON text of the first of samples, the values the
+// types were generated from: the input as given, unless it held several
+// documents or a part of it was selected.
+func sampleText(res *Result, samples []interface{}) []byte {
+	if !res.Samples && res.Select == "" {
+		return []byte(strings.TrimSpace(res.Json))
+	}
+	text, _ := json.Marshal(samples[0])
+	return text
+}
+
+// parseJSON decodes the JSON document s, keeping the text of numbers so
+// none are rounded. Anything after the document is an error, as only
+// samples=on reads more than one.
+func parseJSON(s string) (interface{}, error) {
+	var doc interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("data after the JSON document, use samples=on to read several documents")
+	}
+	return doc, nil
+}
+
+// parseSamples decodes every JSON document in s, one after another, keeping
+// the text of numbers.
+func parseSamples(s string) ([]interface{}, error) {
+	var docs []interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", len(docs)+1, err)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no JSON documents in the input")
+	}
+	return docs, nil
+}
+
+// generateFromAvro converts an Avro schema to Go structs tagged for both
+// Avro and JSON encoding.
+func generateFromAvro(res *Result) error {
+	if res.Format != "" && res.Format != "go" {
+		return fmt.Errorf("Avro schemas can only be converted to Go, not %q", res.Format)
+	}
+	if res.Select != "" {
+		return fmt.Errorf("select only applies to JSON input")
+	}
+	t, err := schema.ParseAvro(strings.NewReader(res.Json), "MyJsonName")
+	if err != nil {
+		return err
+	}
+	naming, err := res.naming()
+	if err != nil {
+		return err
+	}
+	overrides, err := schema.ParseOverrides(res.Overrides)
+	if err != nil {
+		return err
+	}
+	res.Warnings = overrides.Apply(t)
+	goOpts := schema.GoOptions{Tags: []string{"avro", "json"}, Naming: naming}
+	if goOpts.FormatTypes, err = res.formatTypes(); err != nil {
+		return err
+	}
+	out, err := schema.Go(t, goOpts)
+	if out == nil {
+		return err
+	}
+	res.Files = []File{{"types.go", string(out)}}
+	if !res.check(t, goOpts) {
+		return nil
+	}
+	res.Warnings = append(res.Warnings, schema.GoNaming(t, goOpts)...)
+	return err
+}
+
+// check type checks the Go files in res, generated from t with opts,
+// withholding those that fail. It reports whether all of them passed. The
+// other files are checked along with types.go, which declares the types
+// they refer to, and are withheld with it.
+func (res *Result) check(t *schema.Type, opts schema.GoOptions) bool {
+	var pkg map[string][]byte
+	for _, f := range res.Files {
+		if f.Name == "types.go" {
+			if diags := schema.CheckGo(t, opts, f.Name, []byte(f.Content), nil); len(diags) > 0 {
+				res.Diagnostics = diags
+				res.Files = res.Files[:0]
+				return false
+			}
+			pkg = map[string][]byte{f.Name: []byte(f.Content)}
+		}
+	}
+	files := res.Files[:0]
+	for _, f := range res.Files {
+		if strings.HasSuffix(f.Name, ".go") && f.Name != "types.go" {
+			if diags := schema.CheckGo(t, opts, f.Name, []byte(f.Content), pkg); len(diags) > 0 {
+				res.Diagnostics = append(res.Diagnostics, diags...)
+				continue
+			}
+		}
+		files = append(files, f)
+	}
+	res.Files = files
+	return len(res.Diagnostics) == 0
+}
+
 func main() {
 	// reload tempalate on SIGHUP
 	sigc := make(chan os.Signal, 1)
@@ -120,8 +916,18 @@ func main() {
 	flag.IntVar(&Port, "port", 8080, "startup port")
 	flag.StringVar(&Listen, "listen", "localhost", "listen address")
 	flag.StringVar(&Template, "template", "index.html", "display template")
+	flag.StringVar(&Initialisms, "initialisms", "", "extra Go initialisms, comma separated, such as SKU,ARN,OAuth,IAM")
+	flag.StringVar(&PresetFile, "presets", "", "JSON file of named option presets, reloaded on SIGHUP")
 	flag.Parse()
 
+	if PresetFile != "" {
+		p, err := loadPresets(PresetFile)
+		if err != nil {
+			log.Fatalf("at=main error=%v", err)
+		}
+		presets = p
+	}
+
 	handler := Handler{}
 
 	server := &http.Server{
@@ -146,6 +952,16 @@ func reloadTemplate(sigc chan os.Signal) {
 		mutty.Lock()
 		Tmpl = t
 		mutty.Unlock()
+		if PresetFile != "" {
+			p, err := loadPresets(PresetFile)
+			if err != nil {
+				log.Printf("at=reloadTemplate error=%v", err)
+				continue
+			}
+			mutty.Lock()
+			presets = p
+			mutty.Unlock()
+		}
         log.Println("at=reloadTemplate message=\"reloading template\"")
 	}
 }
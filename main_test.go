@@ -0,0 +1,263 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	Template = "index.html"
+	os.Exit(m.Run())
+}
+
+// serve answers a GET request for path with the query q.
+func serve(path string, q url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	Handler{}.ServeHTTP(w, httptest.NewRequest("GET", path+"?"+q.Encode(), nil))
+	return w
+}
+
+func TestFetchError(t *testing.T) {
+	// nothing listens once the server is closed
+	srv := httptest.NewServer(http.NotFoundHandler())
+	src := srv.URL
+	srv.Close()
+	q := url.Values{"src": {src}}
+
+	w := serve("/api", q)
+	var res Result
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || !strings.HasPrefix(res.Error, "JSON Fetch Error") {
+		t.Errorf("/api: status %d, error %q", w.Code, res.Error)
+	}
+
+	w = serve("/", q)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "JSON Fetch Error") {
+		t.Errorf("/: status %d, page without the error", w.Code)
+	}
+}
+
+func TestReadOptions(t *testing.T) {
+	presets = map[string]Options{"shop": {Style: "camel", Initialisms: "SKU", Example: true}}
+	defer func() { presets = nil }()
+
+	tests := []struct {
+		query string
+		want  Options
+	}{
+		{"", Options{}},
+		{"format=graphql&flatten=on&example=on", Options{Format: "graphql", Flatten: true, Example: true}},
+		{"naming=prefix&prefix=Field&style=camel&initialisms=SKU,ARN", Options{Naming: "prefix", Prefix: "Field", Style: "camel", Initialisms: "SKU,ARN"}},
+		{"preset=shop", Options{Style: "camel", Initialisms: "SKU", Example: true, Preset: "shop"}},
+		{"preset=shop&style=go", Options{Style: "go", Initialisms: "SKU", Example: true, Preset: "shop"}},
+		{"preset=missing", Options{Preset: "missing"}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+		if got := readOptions(r); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	preset := Options{Format: "sql-postgres", Flatten: true, Style: "camel", Initialisms: "SKU"}
+	got := Options{Style: "go", Example: true}.withDefaults(preset)
+	want := Options{Format: "sql-postgres", Flatten: true, Example: true, Style: "go", Initialisms: "SKU"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	Initialisms = "ARN"
+	defer func() { Initialisms = "" }()
+
+	n, err := Options{Naming: "spell", Prefix: "F", Style: "camel", Initialisms: "SKU, OAuth"}.naming()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"SKU", "OAuth", "ARN"}; !reflect.DeepEqual(n.Initialisms, want) {
+		t.Errorf("initialisms %q, want %q", n.Initialisms, want)
+	}
+	for _, o := range []Options{{Naming: "upper"}, {Style: "snake"}, {Prefix: "x"}, {Initialisms: "9A"}} {
+		if _, err := o.naming(); err == nil {
+			t.Errorf("%+v: no error", o)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	res := Result{Options: Options{Format: "graphql", Overrides: `{"$.a": {"omit": true}}`}, Json: `{"a": 1}`}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Host = "example.com"
+	permalink, curl := links(r, res, "")
+	if want := "http://example.com/?format=graphql&json=%7B%22a%22%3A+1%7D&overrides=%7B%22%24.a%22%3A+%7B%22omit%22%3A+true%7D%7D"; permalink != want {
+		t.Errorf("permalink %s, want %s", permalink, want)
+	}
+	want := "curl -s 'http://example.com/api' \\\n" +
+		"    --data-urlencode json@input.json \\\n" +
+		"    --data-urlencode 'format=graphql' \\\n" +
+		"    --data-urlencode 'overrides={\"$.a\": {\"omit\": true}}'"
+	if curl != want {
+		t.Errorf("curl\n%s\nwant\n%s", curl, want)
+	}
+
+	// fetched input is linked by its source, over https behind a proxy
+	r.Header.Set("X-Forwarded-Proto", "https")
+	permalink, curl = links(r, res, "http://src.test/a.json")
+	if want := "https://example.com/?format=graphql&overrides=%7B%22%24.a%22%3A+%7B%22omit%22%3A+true%7D%7D&src=http%3A%2F%2Fsrc.test%2Fa.json"; permalink != want {
+		t.Errorf("permalink %s, want %s", permalink, want)
+	}
+	if !strings.HasPrefix(curl, "curl -sG 'https://example.com/api'") || !strings.Contains(curl, "'src=http://src.test/a.json'") {
+		t.Errorf("curl %s", curl)
+	}
+
+	// input too long to link is left out
+	res.Json = strings.Repeat(" ", maxPermalink)
+	if permalink, _ := links(r, res, ""); permalink != "" {
+		t.Errorf("permalink of %d bytes", len(permalink))
+	}
+}
+
+func TestParseSamples(t *testing.T) {
+	docs, err := parseSamples("{\"a\": 1}\n{\"a\": 2} [3]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 {
+		t.Errorf("got %d documents, want 3", len(docs))
+	}
+
+	for _, s := range []string{"", "  \n", `{"a": 1} {"a":`} {
+		if _, err := parseSamples(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	w := serve("/api", url.Values{"json": {`{"id": 18446744073709551616, "small": 1}`}, "int32": {"on"}})
+	var res Result
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 1 {
+		t.Fatalf("status %d, error %q", w.Code, res.Error)
+	}
+	for _, want := range []string{"ID    *big.Int", "Small int32"} {
+		if !strings.Contains(res.Files[0].Content, want) {
+			t.Errorf("no %q in\n%s", want, res.Files[0].Content)
+		}
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].Path != "$.id" {
+		t.Errorf("warnings %v", res.Warnings)
+	}
+}
+
+func TestFormatsCheckbox(t *testing.T) {
+	for _, tt := range []struct {
+		q       url.Values
+		checked bool
+	}{
+		{url.Values{}, false},
+		{url.Values{"formats": {"on"}}, true},
+	} {
+		body := serve("/", tt.q).Body.String()
+		if checked := strings.Contains(body, `name="formats" checked`); checked != tt.checked {
+			t.Errorf("%v: formats checked is %v", tt.q, checked)
+		}
+		// the output list is still offered
+		if !strings.Contains(body, `<option value="graphql"`) {
+			t.Errorf("%v: no output formats in the page", tt.q)
+		}
+	}
+}
+
+func TestFastJSON(t *testing.T) {
+	for _, tt := range []struct {
+		q    url.Values
+		want []string
+	}{
+		{url.Values{"json": {`{"a": 1}`}, "fast-json": {"on"}}, []string{"types.go", "types_json.go", "types_test.go"}},
+		{url.Values{"json": {`{"a": 1}`}, "fast-json": {"on"}, "extra": {"on"}}, []string{"types.go", "types_json.go", "types_reflect.go", "types_test.go"}},
+	} {
+		w := serve("/api", tt.q)
+		var res Result
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range res.Files {
+			names = append(names, f.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) || res.Error != "" || len(res.Diagnostics) > 0 {
+			t.Errorf("%v: files %v, error %q, diagnostics %v", tt.q, names, res.Error, res.Diagnostics)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	if _, err := parseJSON(" {\"a\": 1}\n\n"); err != nil {
+		t.Errorf("trailing space: %v", err)
+	}
+	for _, s := range []string{`{"a": 1} {"a": 2}`, `{"a": 1}}`, `{"a": 1} x`, `[1] 2`} {
+		if _, err := parseJSON(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestSampleText(t *testing.T) {
+	docs, err := parseSamples(`{"a": 1.50}` + "\n" + `{"a": 2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		res  Result
+		want string
+	}{
+		{Result{Json: " {\"a\": 1.50}\n"}, `{"a": 1.50}`},
+		{Result{Options: Options{Samples: true}}, `{"a":1.50}`},
+	}
+	for _, tt := range tests {
+		if got := string(sampleText(&tt.res, docs)); got != tt.want {
+			t.Errorf("%+v: got %s, want %s", tt.res.Options, got, tt.want)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	w := serve("/download", url.Values{"json": {`{"a": 1}`}, "tests": {"on"}})
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Fatalf("content type %q: %s", ct, w.Body)
+	}
+	z, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"types.go", "types_test.go"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files %v, want %v", names, want)
+	}
+
+	// errors are shown in the page instead
+	w = serve("/download", url.Values{"json": {`{"a": 1} {"a": 2}`}})
+	if ct := w.Header().Get("Content-Type"); strings.Contains(ct, "zip") || !strings.Contains(w.Body.String(), "data after the JSON document") {
+		t.Errorf("content type %q for an error", ct)
+	}
+}
@@ -0,0 +1,347 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type avroRecord struct {
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Fields []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Doc     string          `json:"doc,omitempty"`
+	Type    interface{}     `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type avroArray struct {
+	Type  string      `json:"type"`
+	Items interface{} `json:"items"`
+}
+
+type avroMap struct {
+	Type   string      `json:"type"`
+	Values interface{} `json:"values"`
+}
+
+type avroLogical struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+}
+
+type avroWriter struct {
+	names   map[string]string // object type name -> record name
+	used    map[string]bool
+	defined map[string]bool
+}
+
+// Avro renders t as an Avro schema. Records are declared where first used
+// and referred to by name afterwards, optional and nullable fields are
+// unions with null defaulting to null, and timestamps use logical types.
+func Avro(t *Type) ([]byte, error) {
+	a := &avroWriter{names: make(map[string]string), used: make(map[string]bool), defined: make(map[string]bool)}
+	return json.MarshalIndent(a.schema(t), "", "  ")
+}
+
+func (a *avroWriter) schema(t *Type) interface{} {
+	if t == nil {
+		return "null"
+	}
+
+	var s interface{}
+	switch t.Kind {
+	case Null:
+		return "null"
+	case Bool:
+		s = "boolean"
+	case Int:
+		s = "long"
+		if t.Format == "int32" {
+			s = "int"
+		}
+	case Float:
+		s = "double"
+		if t.Format == "float" {
+			s = "float"
+		}
+	case String:
+		switch t.Format {
+		case "date-time":
+			s = avroLogical{"long", "timestamp-millis"}
+		case "date":
+			s = avroLogical{"int", "date"}
+		case "byte":
+			s = "bytes"
+		case "uuid":
+			s = avroLogical{"string", "uuid"}
+		default:
+			s = "string"
+		}
+	case Object:
+		s = a.record(t)
+	case Array:
+		s = avroArray{"array", a.schema(t.Elem)}
+	case Map:
+		s = avroMap{"map", a.schema(t.Elem)}
+	default:
+		// Avro has no dynamic type; keep the JSON text
+		s = "string"
+	}
+	if t.Nullable {
+		return []interface{}{"null", s}
+	}
+	return s
+}
+
+func (a *avroWriter) record(t *Type) interface{} {
+	if a.defined[t.Name] {
+		return a.names[t.Name]
+	}
+	name := avroName(t.Name)
+	base := name
+	for i := 2; a.used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	a.used[name] = true
+	a.names[t.Name] = name
+	a.defined[t.Name] = true
+
+	r := avroRecord{Type: "record", Name: name, Fields: []avroField{}}
+	fields := make(map[string]bool)
+	for _, f := range t.Fields {
+		af := avroField{Name: avroName(f.Key)}
+		base := af.Name
+		for i := 2; fields[af.Name]; i++ {
+			af.Name = base + "_" + strconv.Itoa(i)
+		}
+		fields[af.Name] = true
+		if af.Name != f.Key {
+			af.Doc = "JSON key: " + f.Key
+		}
+
+		af.Type = a.schema(f.Type)
+		union, ok := af.Type.([]interface{})
+		if !ok && t.Optional(f) && af.Type != "null" {
+			union, ok = []interface{}{"null", af.Type}, true
+			af.Type = union
+		}
+		if ok || af.Type == "null" {
+			af.Default = json.RawMessage("null")
+		}
+		if f.Type != nil && f.Type.Kind == Any {
+			af.Doc = strings.TrimSpace(af.Doc + " (JSON encoded)")
+		}
+		r.Fields = append(r.Fields, af)
+	}
+	return r
+}
+
+// avroName replaces characters Avro does not allow in names.
+func avroName(s string) string {
+	name := strings.Map(func(c rune) rune {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			return c
+		}
+		return '_'
+	}, s)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+type avroParser struct {
+	named map[string]*Type
+}
+
+// ParseAvro reads an Avro schema as a Type. Fields keep their declared
+// order, unions with null are nullable and other unions are Any. A root
+// that is not a record is called name.
+func ParseAvro(r io.Reader, name string) (*Type, error) {
+	var v interface{}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	p := avroParser{named: make(map[string]*Type)}
+	t, err := p.parse(v, "")
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind != Object {
+		t.Name = name
+	}
+	setPaths(t, "$")
+	return t, nil
+}
+
+// setPaths locates the types under t as Infer does. A named type keeps the
+// path of its first use.
+func setPaths(t *Type, path string) {
+	if t == nil || t.Path != "" {
+		return
+	}
+	t.Path = path
+	switch t.Kind {
+	case Object:
+		for _, f := range t.Fields {
+			setPaths(f.Type, childPath(path, f.Key))
+		}
+	case Array:
+		setPaths(t.Elem, path+"[]")
+	case Map:
+		setPaths(t.Elem, path+"[*]")
+	}
+}
+
+func (p avroParser) parse(v interface{}, namespace string) (*Type, error) {
+	switch v := v.(type) {
+	case string:
+		return p.primitive(v, namespace)
+	case []interface{}:
+		return p.union(v, namespace)
+	case map[string]interface{}:
+		typ, _ := v["type"].(string)
+		switch typ {
+		case "record", "error":
+			return p.record(v, namespace)
+		case "enum", "fixed":
+			t := &Type{Kind: String, Count: 1}
+			if typ == "fixed" {
+				t.Format = "byte"
+			}
+			_, err := p.define(t, v, namespace)
+			return t, err
+		case "array", "map":
+			key := "items"
+			t := &Type{Kind: Array, Count: 1}
+			if typ == "map" {
+				key = "values"
+				t.Kind = Map
+			}
+			elem, err := p.parse(v[key], namespace)
+			t.Elem = elem
+			return t, err
+		}
+		if typ == "" {
+			return p.parse(v["type"], namespace)
+		}
+		t, err := p.primitive(typ, namespace)
+		if err != nil {
+			return nil, err
+		}
+		switch v["logicalType"] {
+		case "timestamp-millis", "timestamp-micros", "local-timestamp-millis", "local-timestamp-micros":
+			t = &Type{Kind: String, Format: "date-time", Count: 1}
+		case "uuid":
+			t = &Type{Kind: String, Format: "uuid", Count: 1}
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("avro: unexpected schema %v", v)
+}
+
+func (p avroParser) primitive(name, namespace string) (*Type, error) {
+	switch name {
+	case "null":
+		return &Type{Kind: Null, Nullable: true, Count: 1}, nil
+	case "boolean":
+		return &Type{Kind: Bool, Count: 1}, nil
+	case "int":
+		return &Type{Kind: Int, Format: "int32", Count: 1}, nil
+	case "long":
+		return &Type{Kind: Int, Count: 1}, nil
+	case "float":
+		return &Type{Kind: Float, Format: "float", Count: 1}, nil
+	case "double":
+		return &Type{Kind: Float, Count: 1}, nil
+	case "bytes":
+		return &Type{Kind: String, Format: "byte", Count: 1}, nil
+	case "string":
+		return &Type{Kind: String, Count: 1}, nil
+	}
+	if t, ok := p.named[namespace+"."+name]; ok {
+		return t, nil
+	}
+	if t, ok := p.named[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("avro: unknown type %q", name)
+}
+
+func (p avroParser) union(branches []interface{}, namespace string) (*Type, error) {
+	var types []*Type
+	nullable := false
+	for _, b := range branches {
+		if b == "null" {
+			nullable = true
+			continue
+		}
+		t, err := p.parse(b, namespace)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+
+	switch len(types) {
+	case 0:
+		return &Type{Kind: Null, Nullable: true, Count: 1}, nil
+	case 1:
+		// copy, named types are shared with every other reference
+		t := *types[0]
+		t.Nullable = t.Nullable || nullable
+		return &t, nil
+	}
+	return &Type{Kind: Any, Nullable: nullable, Count: 1}, nil
+}
+
+func (p avroParser) record(v map[string]interface{}, namespace string) (*Type, error) {
+	t := &Type{Kind: Object, Count: 1}
+	namespace, err := p.define(t, v, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, _ := v["fields"].([]interface{})
+	for _, f := range fields {
+		f, _ := f.(map[string]interface{})
+		key, _ := f["name"].(string)
+		if key == "" {
+			return nil, fmt.Errorf("avro: record %s has a field without a name", t.Name)
+		}
+		ft, err := p.parse(f["type"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		t.Fields = append(t.Fields, &Field{Key: key, Type: ft, Count: 1})
+	}
+	return t, nil
+}
+
+// define registers a named type under its simple and full names, returning
+// the namespace its own members are resolved in.
+func (p avroParser) define(t *Type, v map[string]interface{}, namespace string) (string, error) {
+	name, _ := v["name"].(string)
+	if name == "" {
+		return "", fmt.Errorf("avro: %v type without a name", v["type"])
+	}
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+	if t.Kind == Object {
+		t.Name = name
+	}
+	p.named[name] = t
+	p.named[namespace+"."+name] = t
+	return namespace, nil
+}
@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAvro(t *testing.T) {
+	avsc, err := Avro(fixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "avro", avsc)
+}
+
+func TestParseAvro(t *testing.T) {
+	tests := []struct {
+		typ      string
+		kind     Kind
+		format   string
+		nullable bool
+	}{
+		{`"boolean"`, Bool, "", false},
+		{`"int"`, Int, "int32", false},
+		{`"long"`, Int, "", false},
+		{`"float"`, Float, "float", false},
+		{`"double"`, Float, "", false},
+		{`"string"`, String, "", false},
+		{`"bytes"`, String, "byte", false},
+		{`["null", "string"]`, String, "", true},
+		{`["string", "long"]`, Any, "", false},
+		{`{"type": "long", "logicalType": "timestamp-millis"}`, String, "date-time", false},
+		{`{"type": "enum", "name": "Color", "symbols": ["RED"]}`, String, "", false},
+		{`{"type": "array", "items": "string"}`, Array, "", false},
+		{`{"type": "map", "values": "long"}`, Map, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			avsc := `{"type": "record", "name": "Root", "fields": [{"name": "v", "type": ` + tt.typ + `}]}`
+			root, err := ParseAvro(strings.NewReader(avsc), "Root")
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := root.Fields[0].Type
+			if v.Kind != tt.kind || v.Format != tt.format || v.Nullable != tt.nullable {
+				t.Errorf("got %v %q nullable=%v, want %v %q nullable=%v", v.Kind, v.Format, v.Nullable, tt.kind, tt.format, tt.nullable)
+			}
+		})
+	}
+}
+
+func TestParseAvroErrors(t *testing.T) {
+	for _, avsc := range []string{
+		`{"type": "record", "name": "Root", "fields": [{"name": "v", "type": "Missing"}]}`,
+		`{"type": "record", "fields": []}`,
+		`{"type": "record", "name": "Root", "fields": [{"type": "string"}]}`,
+		`{`,
+	} {
+		if _, err := ParseAvro(strings.NewReader(avsc), "Root"); err == nil {
+			t.Errorf("%s: no error", avsc)
+		}
+	}
+}
+
+// TestAvroGo reads the schema Avro writes for the fixture back as Go types.
+func TestAvroGo(t *testing.T) {
+	avsc, err := Avro(fixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ParseAvro(bytes.NewReader(avsc), "Customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Go(root, GoOptions{Tags: []string{"avro", "json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "avro_go", out)
+}
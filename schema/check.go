@@ -0,0 +1,263 @@
+package schema
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Diagnostic is an error found by type checking generated Go source.
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Key     string `json:"key,omitempty"`  // input key of the offending field
+	Path    string `json:"path,omitempty"` // input location of the offending field or type
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s:%d:%d: ", d.File, d.Line, d.Column)
+	if d.Path != "" {
+		s += d.Path + ": "
+	} else if d.Key != "" {
+		s += strconv.Quote(d.Key) + ": "
+	}
+	return s + d.Message
+}
+
+// The source importer type checks imported standard library packages from
+// GOROOT and caches them; it is not safe for concurrent use.
+var (
+	importMu    sync.Mutex
+	stdImporter = importer.ForCompiler(token.NewFileSet(), "source", nil)
+)
+
+type checker struct {
+	fset  *token.FileSet
+	file  *ast.File
+	paths map[string]string // Go type name -> input path
+	diags []Diagnostic
+}
+
+// CheckGo parses and type checks src, a file generated for t with opts, and
+// checks its struct tags. Each error is located by the key in the tag of the
+// struct field it falls in. t may be nil for files not generated from a Type.
+// pkg holds other files of the package by name, which src may refer to;
+// they are type checked along with it but their errors are not reported.
+func CheckGo(t *Type, opts GoOptions, filename string, src []byte, pkg map[string][]byte) []Diagnostic {
+	c := &checker{fset: token.NewFileSet(), paths: make(map[string]string)}
+	if t != nil {
+		if t.Kind != Object {
+			c.paths[opts.Naming.typeName(t.Name)] = t.Path
+		}
+		g := newGoWriter(t, opts)
+		for _, o := range declarationOrder(t) {
+			c.paths[g.names[o.Name]] = o.Path
+		}
+	}
+
+	f, err := parser.ParseFile(c.fset, filename, src, parser.ParseComments)
+	if err != nil {
+		if list, ok := err.(scanner.ErrorList); ok {
+			lines := strings.Split(string(src), "\n")
+			for _, e := range list {
+				d := Diagnostic{File: filename, Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Msg}
+				d.Key, d.Path = c.locateLine(lines, e.Pos.Line)
+				c.diags = append(c.diags, d)
+			}
+		} else {
+			c.diags = append(c.diags, Diagnostic{File: filename, Message: err.Error()})
+		}
+		return c.diags
+	}
+	c.file = f
+	files := []*ast.File{f}
+	names := make([]string, 0, len(pkg))
+	for name := range pkg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if other, err := parser.ParseFile(c.fset, name, pkg[name], 0); err == nil {
+			files = append(files, other)
+		}
+	}
+
+	conf := types.Config{
+		Importer: stdImporter,
+		Error: func(err error) {
+			e, ok := err.(types.Error)
+			// a missing GOROOT is no fault of the generated code
+			if !ok || strings.HasPrefix(e.Msg, "could not import") || c.fset.Position(e.Pos).Filename != filename {
+				return
+			}
+			c.add(e.Pos, strings.TrimSpace(e.Msg))
+		},
+	}
+	importMu.Lock()
+	conf.Check(f.Name.Name, c.fset, files, nil)
+	importMu.Unlock()
+
+	c.checkTags()
+	return c.diags
+}
+
+// add records msg at pos along with the key and path of the struct field,
+// or the type, enclosing it.
+func (c *checker) add(pos token.Pos, msg string) {
+	p := c.fset.Position(pos)
+	d := Diagnostic{File: p.Filename, Line: p.Line, Column: p.Column, Message: msg}
+
+	var typeName string
+	ast.Inspect(c.file, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			typeName = n.Name.Name
+			d.Path = c.paths[typeName]
+		case *ast.Field:
+			if key, ok := fieldKey(n); ok {
+				d.Key = key
+				if path := c.paths[typeName]; path != "" {
+					d.Path = childPath(path, key)
+				}
+			}
+		}
+		return true
+	})
+	c.diags = append(c.diags, d)
+}
+
+// locateLine finds the key and path of the field declared on a line of
+// source that does not parse: the generator writes one field per line, its
+// key quoted at the start of the tag, below the line declaring its type.
+func (c *checker) locateLine(lines []string, line int) (key, path string) {
+	if line < 1 || line > len(lines) {
+		return "", ""
+	}
+	text := lines[line-1]
+	i := strings.Index(text, ":\"")
+	j := strings.LastIndex(text, "\"")
+	if !strings.Contains(text, "`") || i < 0 || j <= i+1 {
+		return "", ""
+	}
+	key = text[i+2 : j]
+	for n := line - 1; n >= 0; n-- {
+		if f := strings.Fields(lines[n]); len(f) >= 3 && f[0] == "type" {
+			if path = c.paths[f[1]]; path != "" {
+				path = childPath(path, key)
+			}
+			break
+		}
+	}
+	return key, path
+}
+
+// checkTags reports malformed struct tags, json names encoding/json would
+// ignore and tagged fields it cannot see because they are unexported.
+func (c *checker) checkTags() {
+	ast.Inspect(c.file, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil || !validTag(tag) {
+			c.add(field.Tag.Pos(), fmt.Sprintf("struct tag %s is not in canonical key:\"value\" form", field.Tag.Value))
+			return true
+		}
+		name, ok := reflect.StructTag(tag).Lookup("json")
+		if !ok || name == "-" {
+			return true
+		}
+		if name = strings.Split(name, ",")[0]; !validJSONName(name) {
+			c.add(field.Tag.Pos(), fmt.Sprintf("json name %q is not accepted by encoding/json", name))
+		}
+		for _, id := range field.Names {
+			if !id.IsExported() {
+				c.add(id.Pos(), fmt.Sprintf("field %s has a json tag but is not exported, encoding/json ignores it", id.Name))
+			}
+		}
+		return true
+	})
+}
+
+// fieldKey returns the key named by the json, db or avro tag of field.
+func fieldKey(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	for _, name := range []string{"json", "db", "avro"} {
+		if v, ok := reflect.StructTag(tag).Lookup(name); ok {
+			return strings.Split(v, ",")[0], true
+		}
+	}
+	return "", false
+}
+
+// validTag reports whether tag follows the key:"value" convention read by
+// reflect.StructTag.
+func validTag(tag string) bool {
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return false
+		}
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return false
+		}
+		if _, err := strconv.Unquote(tag[:i+1]); err != nil {
+			return false
+		}
+		tag = tag[i+1:]
+		if tag != "" && tag[0] != ' ' {
+			return false
+		}
+		tag = strings.TrimLeft(tag, " ")
+	}
+	return true
+}
+
+// validJSONName mirrors the names encoding/json accepts from a tag.
+func validJSONName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckGo(t *testing.T) {
+	root := Infer("Root", decode(t, `{"a": 1, "b": {"c": 2}}`)...)
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"clean", "package main\n\ntype Root struct {\n\tA int64 `json:\"a\"`\n}\n", nil},
+		{"syntax", "package main\n\ntype Root struct {\n\t1A int64 `json:\"a\"`\n}\n",
+			[]string{`types.go:4:2: $.a: expected '}', found 1`}},
+		{"type", "package main\n\ntype B struct {\n\tC missing `json:\"c\"`\n}\n",
+			[]string{`types.go:4:4: $.b.c: undefined: missing`}},
+		{"tag form", "package main\n\ntype Root struct {\n\tA int64 `json:a`\n}\n",
+			[]string{"types.go:4:10: $: struct tag `json:a` is not in canonical key:\"value\" form"}},
+		{"json name", "package main\n\ntype Root struct {\n\tA int64 `json:\"a\\\\b\"`\n}\n",
+			[]string{`types.go:4:10: $["a\\b"]: json name "a\\b" is not accepted by encoding/json`}},
+		{"unexported", "package main\n\ntype Root struct {\n\ta int64 `json:\"a\"`\n}\n",
+			[]string{`types.go:4:2: $.a: field a has a json tag but is not exported, encoding/json ignores it`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range CheckGo(root, GoOptions{}, "types.go", []byte(tt.src), nil) {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxExample caps the length of the example values in conflict warnings.
+const maxExample = 40
+
+// Conflicting reports whether t was widened to Any because values of
+// different kinds were seen at its position.
+func Conflicting(t *Type) bool {
+	return t != nil && t.Kind == Any && t.Format == "" && len(t.Seen) > 1 &&
+		(t.Override == nil || t.Override.Type == "")
+}
+
+// Conflicts reports each position of t that took values of conflicting
+// kinds, such as the elements of an array mixing strings and objects, with
+// the kinds seen and the first value of each. The variants of unions are
+// looked into rather than their merged fields.
+func Conflicts(t *Type) []Warning {
+	return conflicts(t, func(*Type) string { return "widened to any" })
+}
+
+// GoConflicts is Conflicts worded after the Go type opts gives each
+// position: interface{}, json.RawMessage or a union struct.
+func GoConflicts(t *Type, opts GoOptions) []Warning {
+	g := newGoWriter(t, opts)
+	return conflicts(t, func(t *Type) string {
+		switch typ := g.mixedType(t); typ {
+		case "interface{}":
+			return "widened to interface{}"
+		case "json.RawMessage":
+			return "kept as json.RawMessage"
+		default:
+			return "held in " + typ
+		}
+	})
+}
+
+// conflicts reports the positions of t that took values of conflicting
+// kinds, saying what became of them with as.
+func conflicts(t *Type, as func(*Type) string) []Warning {
+	var warnings []Warning
+	seen := make(map[*Type]bool)
+	var walk func(t *Type)
+	walk = func(t *Type) {
+		if t == nil || seen[t] {
+			return
+		}
+		seen[t] = true
+		if Conflicting(t) {
+			warnings = append(warnings, Warning{t.Path, conflictMessage(t, as(t))})
+		}
+		switch t.Kind {
+		case Object:
+			if isUnion(t) {
+				for _, v := range t.Variants {
+					walk(v.Type)
+				}
+				return
+			}
+			for _, f := range t.Fields {
+				walk(f.Type)
+			}
+		case Array, Map:
+			walk(t.Elem)
+		}
+	}
+	walk(t)
+	return warnings
+}
+
+func conflictMessage(t *Type, as string) string {
+	examples := make([]string, len(t.Seen))
+	for i, k := range t.Seen {
+		examples[i] = k.String() + " " + exampleValue(t.first[k])
+	}
+	return "values of several types were seen, " + as + ": " + strings.Join(examples, ", ")
+}
+
+// exampleValue renders v as JSON, shortened to maxExample characters.
+func exampleValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := string(b)
+	if utf8.RuneCountInString(s) > maxExample {
+		s = string([]rune(s)[:maxExample-1]) + "…"
+	}
+	return s
+}
+
+// conflictKinds lists the kinds a union must hold for the values of t, in
+// kind order. Ints are held as floats when floats were seen too.
+func conflictKinds(t *Type) []Kind {
+	var kinds []Kind
+	float := false
+	for _, k := range t.Seen {
+		float = float || k == Float
+	}
+	for _, k := range t.Seen {
+		if k != Int || !float {
+			kinds = append(kinds, k)
+		}
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mixedDoc = `{"id": 1, "tags": ["a", 2]} {"id": "b", "tags": [{"k": 1}]}`
+
+func TestConflicts(t *testing.T) {
+	root := Infer("Root", decode(t, mixedDoc)...)
+	want := []Warning{
+		{"$.id", `values of several types were seen, widened to any: int 1, string "b"`},
+		{"$.tags[]", `values of several types were seen, widened to any: string "a", int 2, object {"k":1}`},
+	}
+	if got := Conflicts(root); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if got := Conflicts(Infer("Root", decode(t, `{"v": 1} {"v": 1.5} {"v": null}`)...)); got != nil {
+		t.Errorf("numbers and null conflict: %v", got)
+	}
+}
+
+func TestGoConflicts(t *testing.T) {
+	tests := []struct {
+		mode MixedMode
+		want []string
+	}{
+		{MixedAny, []string{"widened to interface{}", "widened to interface{}"}},
+		{MixedRaw, []string{"kept as json.RawMessage", "kept as json.RawMessage"}},
+		{MixedUnion, []string{"held in IntOrString", "held in IntOrStringOrObject"}},
+	}
+	for _, tt := range tests {
+		root := Infer("Root", decode(t, mixedDoc)...)
+		got := GoConflicts(root, GoOptions{Mixed: tt.mode})
+		if len(got) != len(tt.want) {
+			t.Fatalf("mode %d: got %v", tt.mode, got)
+		}
+		for i, w := range got {
+			if !strings.Contains(w.Message, ", "+tt.want[i]+": ") {
+				t.Errorf("mode %d: %s does not say %q", tt.mode, w.Message, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestGoMixed(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		mode MixedMode
+	}{
+		{"go_mixed_any", MixedAny},
+		{"go_mixed_raw", MixedRaw},
+		{"go_mixed_union", MixedUnion},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Infer("Root", decode(t, mixedDoc)...)
+			opts := GoOptions{Tags: []string{"json"}, Mixed: tt.mode}
+			out, err := Go(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+				t.Errorf("diagnostics: %v", diags)
+			}
+			golden(t, tt.name, out)
+		})
+	}
+}
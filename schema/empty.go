@@ -0,0 +1,24 @@
+package schema
+
+// Empty reports the arrays under t that were only seen empty, whose
+// element type is unknown, and the objects that were only seen without
+// keys, which get a type with no fields. Types an override gives a Go type
+// are left out, and so are arrays whose elements an override of path[]
+// describes.
+func Empty(t *Type) []Warning {
+	var warnings []Warning
+	walkTypes(t, func(t *Type) {
+		if t.Override != nil && t.Override.Type != "" {
+			return
+		}
+		switch {
+		case t.Kind == Array && t.Elem == nil:
+			warnings = append(warnings, Warning{t.Path,
+				"only empty arrays were seen, so the element type is unknown; add a sample with elements or override " + t.Path + "[]"})
+		case t.Kind == Object && len(t.Fields) == 0 && !isUnion(t):
+			warnings = append(warnings, Warning{t.Path,
+				"only empty objects were seen, so the type has no fields; add a sample with keys or override " + t.Path + " with a map type"})
+		}
+	})
+	return warnings
+}
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmpty(t *testing.T) {
+	root := Infer("Root", decode(t, `{"tags": [], "meta": {}, "items": [{}], "full": [1], "ok": {"a": 1}}`)...)
+	want := []Warning{
+		{"$.items[]", "only empty objects were seen, so the type has no fields; add a sample with keys or override $.items[] with a map type"},
+		{"$.meta", "only empty objects were seen, so the type has no fields; add a sample with keys or override $.meta with a map type"},
+		{"$.tags", "only empty arrays were seen, so the element type is unknown; add a sample with elements or override $.tags[]"},
+	}
+	got := Empty(root)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestEmptyOverrides(t *testing.T) {
+	root := Infer("Root", decode(t, `{"tags": [], "meta": {}}`)...)
+	o, err := ParseOverrides(`{"$.tags[]": {"type": "string"}, "$.meta": {"type": "map[string]string"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := o.Apply(root); len(w) > 0 {
+		t.Errorf("apply: %v", w)
+	}
+	if w := Empty(root); len(w) > 0 {
+		t.Errorf("overridden types reported: %v", w)
+	}
+	out, err := Go(root, GoOptions{Tags: []string{"json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]string{"Tags": "[]string", "Meta": "map[string]string"} {
+		if got := fieldType(out, field); got != want {
+			t.Errorf("%s is %s, want %s", field, got, want)
+		}
+	}
+}
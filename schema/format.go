@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"go/parser"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// textFormats are the formats textFormat recognises, which StringFormats
+// gives to strings, with the names Go notes beside their fields.
+var textFormats = map[string]string{
+	"uuid": "uuid", "ipv4": "ipv4", "ipv6": "ipv6", "email": "email", "uri": "uri", "byte": "base64",
+}
+
+// textFormat names the format of s when it is a UUID, an IP address, an
+// email address, an absolute URI or base64 data, "byte" as OpenAPI calls it.
+func textFormat(s string) string {
+	if uuidPattern.MatchString(s) {
+		return "uuid"
+	}
+	if ip, err := netip.ParseAddr(s); err == nil && ip.Zone() == "" {
+		if ip.Is4() {
+			return "ipv4"
+		}
+		return "ipv6"
+	}
+	if a, err := mail.ParseAddress(s); err == nil && a.Address == s {
+		return "email"
+	}
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" && !strings.ContainsAny(s, " \t\n") {
+		return "uri"
+	}
+	if isBase64(s) {
+		return "byte"
+	}
+	return ""
+}
+
+// isBase64 reports whether s looks like padded standard base64, as
+// encoding/json writes []byte. Words and hex digits are valid base64 too, so
+// s must be long enough and mix upper and lower case letters with digits or
+// symbols.
+func isBase64(s string) bool {
+	if len(s) < 16 || len(s)%4 != 0 {
+		return false
+	}
+	if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+		return false
+	}
+	var upper, lower, other bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		default:
+			other = true
+		}
+	}
+	return upper && lower && other
+}
+
+// StringFormats gives the string types under t whose values all share a
+// format textFormat recognises that format.
+func StringFormats(t *Type) {
+	walkTypes(t, func(t *Type) {
+		if t.Kind == String && t.Format == "" && t.text != "" {
+			t.Format = t.text
+		}
+	})
+	nameTypes(t, t.Name)
+}
+
+// FormatTypes map string formats, such as "uuid", to the Go types declared
+// for strings of that format. Only the Type and Import of each are used.
+type FormatTypes map[string]*Override
+
+// DefaultFormatTypes declare IP addresses as net.IP. Strings of the other
+// formats stay strings, but for base64 data, which is []byte already.
+var DefaultFormatTypes = FormatTypes{
+	"ipv4": {Type: "net.IP"},
+	"ipv6": {Type: "net.IP"},
+}
+
+// ParseFormatTypes reads a comma-separated list of format=type pairs, such
+// as "uuid=github.com/google/uuid.UUID, byte=string", on top of the
+// defaults. A type from outside the standard packages Go knows is written
+// after its import path.
+func ParseFormatTypes(s string) (FormatTypes, error) {
+	types := make(FormatTypes, len(DefaultFormatTypes))
+	for format, ov := range DefaultFormatTypes {
+		types[format] = ov
+	}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		format, typ, ok := strings.Cut(pair, "=")
+		format, typ = strings.TrimSpace(format), strings.TrimSpace(typ)
+		if !ok || format == "" || typ == "" {
+			return nil, fmt.Errorf("format types: %q is not format=type", pair)
+		}
+		if textFormats[format] == "" && format != "date-time" && format != "date" {
+			return nil, fmt.Errorf("format types: unknown format %q", format)
+		}
+		ov := &Override{Type: typ}
+		// github.com/google/uuid.UUID is uuid.UUID from github.com/google/uuid
+		prefix := typ[:len(typ)-len(strings.TrimLeft(typ, "*[]"))]
+		if rest := typ[len(prefix):]; strings.Contains(rest, "/") {
+			slash := strings.LastIndex(rest, "/")
+			dot := strings.Index(rest[slash:], ".")
+			if dot < 0 {
+				return nil, fmt.Errorf("format types: %s: %q names no type", format, typ)
+			}
+			ov.Import = rest[:slash+dot]
+			ov.Type = prefix + path.Base(ov.Import) + rest[slash+dot:]
+		}
+		expr, err := parser.ParseExpr(ov.Type)
+		if err != nil {
+			return nil, fmt.Errorf("format types: %s: type %q: %v", format, typ, err)
+		}
+		if q := qualifier(expr); q != "" && ov.Import == "" && goStdQualifiers[q] == "" {
+			return nil, fmt.Errorf("format types: %s: type %q needs an import path", format, typ)
+		}
+		types[format] = ov
+	}
+	return types, nil
+}
@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTextFormat(t *testing.T) {
+	tests := []struct{ s, want string }{
+		{"123e4567-e89b-12d3-a456-426614174000", "uuid"},
+		{"10.0.0.1", "ipv4"},
+		{"::1", "ipv6"},
+		{"fe80::1%eth0", ""},
+		{"ada@example.com", "email"},
+		{"Ada <ada@example.com>", ""},
+		{"https://example.com/a?b=c", "uri"},
+		{"/relative/path", ""},
+		{"SGVsbG8sIFdvcmxkIQ==", "byte"},
+		{"deadbeefdeadbeef", ""}, // hex digits are base64 too
+		{"Hello", ""},
+	}
+	for _, tt := range tests {
+		if got := textFormat(tt.s); got != tt.want {
+			t.Errorf("textFormat(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStringFormats(t *testing.T) {
+	// CheckGo looks up github.com/google/uuid, which must not be added to
+	// go.mod when GOFLAGS allows it
+	t.Setenv("GOFLAGS", "-mod=readonly")
+	root := Infer("Root", decode(t, `
+		{"id": "123e4567-e89b-12d3-a456-426614174000", "ip": "10.0.0.1", "data": "SGVsbG8sIFdvcmxkIQ==", "home": "https://a.test", "mixed": "a@b.test"}
+		{"id": "223e4567-e89b-12d3-a456-426614174000", "ip": "10.0.0.2", "data": "V29ybGQsIEhlbGxvIQ==", "home": "https://b.test", "mixed": "10.0.0.1"}
+	`)...)
+	StringFormats(root)
+	types, err := ParseFormatTypes("uuid=github.com/google/uuid.UUID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := GoOptions{Tags: []string{"json"}, FormatTypes: types}
+	out, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+		t.Errorf("diagnostics: %v", diags)
+	}
+	golden(t, "go_formats", out)
+
+	out, err = GraphQL(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "graphql_formats", out)
+}
+
+func TestParseFormatTypes(t *testing.T) {
+	got, err := ParseFormatTypes(" uuid = github.com/google/uuid.UUID, ipv4=netip.Addr, byte=string ,date-time=*time.Time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FormatTypes{
+		"uuid":      {Type: "uuid.UUID", Import: "github.com/google/uuid"},
+		"ipv4":      {Type: "netip.Addr"},
+		"ipv6":      {Type: "net.IP"},
+		"byte":      {Type: "string"},
+		"date-time": {Type: "*time.Time"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, s := range []string{"uuid", "color=string", "uuid=", "uuid=github.com/google/uuid", "uuid=uuid.UUID", "uuid=[]("} {
+		if _, err := ParseFormatTypes(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
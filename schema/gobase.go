@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goBase is a struct holding fields declared alike by several structs,
+// which embed it instead.
+type goBase struct {
+	name   string
+	fields []goField // as declared by the first struct embedding it
+	keys   map[string]bool
+	users  []string // Go names of the structs embedding it
+}
+
+// fieldDecl identifies the declaration of f: its name, type, tag and
+// comment.
+func (g *goWriter) fieldDecl(f goField) string {
+	return f.Name + " " + g.typeExpr(f.Type) + " " + g.tag(f) + formatComment(f)
+}
+
+// findBases picks the sets of at least opts.BaseFields fields that structs
+// declare alike and gives each a base struct. Sets are chosen greedily by
+// the declarations they save, and a struct embeds at most one base.
+func (g *goWriter) findBases(root *Type, used map[string]bool) {
+	if g.opts.BaseFields <= 0 {
+		return
+	}
+	var structs []*Type
+	decls := make(map[*Type]map[string]goField)
+	for _, o := range declarationOrder(root) {
+		if isUnion(o) {
+			continue
+		}
+		structs = append(structs, o)
+		decls[o] = make(map[string]goField)
+		for _, f := range g.structFields(o) {
+			decls[o][g.fieldDecl(f)] = f
+		}
+	}
+
+	for {
+		// candidate sets are the declarations two structs share
+		candidates := make(map[string][]string)
+		for i, a := range structs {
+			if g.bases[a.Name] != nil {
+				continue
+			}
+			for _, b := range structs[i+1:] {
+				if g.bases[b.Name] != nil {
+					continue
+				}
+				var shared []string
+				for d := range decls[a] {
+					if _, ok := decls[b][d]; ok {
+						shared = append(shared, d)
+					}
+				}
+				if len(shared) >= g.opts.BaseFields {
+					sort.Strings(shared)
+					candidates[strings.Join(shared, "\n")] = shared
+				}
+			}
+		}
+
+		var best []string
+		var bestUsers []*Type
+		bestKey, bestSaved := "", 0
+		for key, set := range candidates {
+			var users []*Type
+			for _, o := range structs {
+				if g.bases[o.Name] == nil && hasAll(decls[o], set) {
+					users = append(users, o)
+				}
+			}
+			saved := len(set) * (len(users) - 1)
+			if saved > bestSaved || saved == bestSaved && key < bestKey {
+				best, bestUsers, bestKey, bestSaved = set, users, key, saved
+			}
+		}
+		if best == nil {
+			return
+		}
+
+		b := &goBase{keys: make(map[string]bool)}
+		for _, f := range g.structFields(bestUsers[0]) {
+			if contains(best, g.fieldDecl(f)) {
+				b.fields = append(b.fields, f)
+				b.keys[f.Key] = true
+			}
+		}
+		b.name = "Base"
+		for i := 2; used[b.name] || g.fieldNamed(bestUsers, b.name); i++ {
+			b.name = "Base" + strconv.Itoa(i)
+		}
+		used[b.name] = true
+		for _, o := range bestUsers {
+			g.bases[o.Name] = b
+			b.users = append(b.users, g.names[o.Name])
+		}
+		g.baseOrder = append(g.baseOrder, b)
+	}
+}
+
+// fieldNamed reports whether one of the structs declares a field called
+// name, which an embedded struct of that name would clash with.
+func (g *goWriter) fieldNamed(structs []*Type, name string) bool {
+	for _, o := range structs {
+		for _, f := range g.structFields(o) {
+			if f.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasAll(decls map[string]goField, set []string) bool {
+	for _, d := range set {
+		if _, ok := decls[d]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// declareBase writes a base struct.
+func (g *goWriter) declareBase(w *bytes.Buffer, b *goBase) {
+	users := b.users
+	last := len(users) - 1
+	fmt.Fprintf(w, "\n// %s holds the fields %s and %s share.\ntype %s struct {\n",
+		b.name, strings.Join(users[:last], ", "), users[last], b.name)
+	for _, f := range b.fields {
+		fmt.Fprintf(w, "%s %s %s%s\n", f.Name, g.typeExpr(f.Type), tagLiteral(g.tag(f)), formatComment(f))
+	}
+	w.WriteString("}\n")
+}
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// EnumOptions controls the detection of string fields taking a few fixed
+// values; the zero value disables it.
+type EnumOptions struct {
+	MaxValues int  // most distinct values an enum may have
+	MinCount  int  // fewest values that must have been seen
+	Valid     bool // declare a Valid method on each enum type
+}
+
+type goEnum struct {
+	name   string
+	path   string
+	values map[string]int
+	count  int               // strings seen, not counting nulls
+	over   bool              // some member saw too many values
+	consts map[string]string // value -> constant name
+	types  []*Type
+}
+
+// findEnums names the string types that qualify as enums. The string types
+// of one field of every object sharing a declaration are judged together,
+// so the constants cover every value the shared struct was generated from.
+func (g *goWriter) findEnums(root *Type) {
+	if g.opts.Enums.MaxValues <= 0 {
+		return
+	}
+	groups := make(map[string]*goEnum)
+	var order []*goEnum
+	seen := make(map[*Type]bool)
+	var walk func(t *Type, owner, base, key string)
+	walk = func(t *Type, owner, base, key string) {
+		if t == nil || seen[t] || t.Override != nil && t.Override.Type != "" {
+			return
+		}
+		seen[t] = true
+		switch t.Kind {
+		case String:
+			if key == "" || t.Format != "" {
+				return
+			}
+			e, ok := groups[key]
+			if !ok {
+				e = &goEnum{name: owner + base, path: t.Path, values: make(map[string]int)}
+				groups[key] = e
+				order = append(order, e)
+			}
+			e.types = append(e.types, t)
+			e.over = e.over || t.tooMany
+			// the strings only, as t.Count counts nulls too
+			for v, n := range t.Values {
+				e.values[v] += n
+				e.count += n
+			}
+		case Object:
+			if isUnion(t) {
+				// each variant holds one value of the discriminator
+				for _, v := range t.Variants {
+					seen[v.Type] = true
+					name := g.names[v.Type.Name]
+					for _, f := range g.structFields(v.Type) {
+						if f.Key != t.Discriminator {
+							walk(f.Type, name, f.Name, name+"."+f.Key)
+						}
+					}
+				}
+				return
+			}
+			name := g.names[t.Name]
+			for _, f := range g.structFields(t) {
+				walk(f.Type, name, f.Name, name+"."+f.Key)
+			}
+		case Array, Map:
+			walk(t.Elem, owner, singular(base), key+"[]")
+		}
+	}
+	walk(root, "", "", "")
+
+	used := make(map[string]bool)
+	for _, name := range g.names {
+		used[name] = true
+	}
+	for _, m := range g.mixedOrder {
+		used[m.name] = true
+	}
+	for _, e := range order {
+		n := len(e.values)
+		if e.over || n == 0 || n > g.opts.Enums.MaxValues || e.count < g.opts.Enums.MinCount || n >= e.count {
+			continue
+		}
+		base := e.name
+		for i := 2; used[e.name]; i++ {
+			e.name = base + strconv.Itoa(i)
+		}
+		used[e.name] = true
+		g.enums = append(g.enums, e)
+		for _, t := range e.types {
+			g.enumOf[t] = e
+		}
+	}
+	// constants share the package scope with the types
+	for _, e := range g.enums {
+		e.consts = make(map[string]string)
+		for _, v := range sortedCounts(e.values) {
+			c := e.name + "Empty"
+			if v != "" {
+				c = e.name + g.opts.Naming.name(v)
+			}
+			base := c
+			for i := 2; used[c]; i++ {
+				c = base + strconv.Itoa(i)
+			}
+			used[c] = true
+			e.consts[v] = c
+		}
+	}
+}
+
+// declareEnum writes an enum's type, its constants and its Valid method.
+func (g *goWriter) declareEnum(w *bytes.Buffer, e *goEnum) {
+	values := sortedCounts(e.values)
+	fmt.Fprintf(w, "\n// %s is one of the values seen at %s.\ntype %s string\n", e.name, e.path, e.name)
+	w.WriteString("\nconst (\n")
+	for _, v := range values {
+		fmt.Fprintf(w, "%s %s = %s\n", e.consts[v], e.name, strconv.Quote(v))
+	}
+	w.WriteString(")\n")
+	if !g.opts.Enums.Valid {
+		return
+	}
+	fmt.Fprintf(w, "\n// Valid reports whether v is one of the known %s values.\n", e.name)
+	fmt.Fprintf(w, "func (v %s) Valid() bool {\nswitch v {\ncase ", e.name)
+	for i, v := range values {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(e.consts[v])
+	}
+	w.WriteString(":\nreturn true\n}\nreturn false\n}\n")
+}
+
+func sortedCounts(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
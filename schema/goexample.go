@@ -0,0 +1,282 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var goHelpers = map[string]string{
+	"ptr":    "func ptr[T any](v T) *T { return &v }",
+	"bigInt": "func bigInt(s string) *big.Int { n, _ := new(big.Int).SetString(s, 10); return n }",
+}
+
+// literal renders v, one of the values merged into t, as a Go expression of
+// t's Go type. Struct literals inside a slice literal have their type elided
+// when elide is set. It returns "" for values of an overridden type it cannot
+// write, which are left out.
+func (g *goWriter) literal(t *Type, v interface{}, elide bool) string {
+	if t != nil && t.Override != nil && t.Override.Type != "" {
+		return g.overridden(t.Override.Type, v)
+	}
+	if t != nil && t.Kind == Any && t.Format == "json" {
+		return g.overridden("json.RawMessage", v)
+	}
+	if Conflicting(t) {
+		return g.mixedLiteral(t, v)
+	}
+	if t == nil || t.Kind == Null || t.Kind == Any {
+		return g.dynamic(v)
+	}
+	if v == nil {
+		return "nil"
+	}
+	if isUnion(t) {
+		// the interface needs the variant's type named
+		return g.literal(variant(t, v), v, false)
+	}
+	if str, ok := v.(string); ok && t.Quoted {
+		if scalar, ok := scalarOf(str); ok {
+			v = scalar
+		}
+	}
+
+	var s string
+	switch t.Kind {
+	case Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return g.dynamic(v)
+		}
+		s = strconv.FormatBool(b)
+	case Int, Float:
+		n, ok := parseNumber(v)
+		if !ok {
+			return g.dynamic(v)
+		}
+		s = n.text
+		switch t.Format {
+		case "bigint":
+			if n.integer == nil {
+				return ""
+			}
+			g.helpers["bigInt"] = true
+			return "bigInt(" + strconv.Quote(n.integer.String()) + ")"
+		case "decimal":
+			s = "json.Number(" + strconv.Quote(n.text) + ")"
+		}
+	case String:
+		str, ok := v.(string)
+		if !ok {
+			return g.dynamic(v)
+		}
+		if ov := g.opts.FormatTypes[t.Format]; ov != nil && t.Format != "" {
+			s = g.overridden(ov.Type, v)
+			if s == "" || strings.HasPrefix(ov.Type, "[]") || strings.HasPrefix(ov.Type, "*") {
+				return s
+			}
+			break
+		}
+		if t.Format == "byte" {
+			return g.overridden("[]byte", v)
+		}
+		s = strconv.Quote(str)
+		if t.Format == "date-time" {
+			s = g.timeLiteral(str)
+		}
+		if e := g.enumOf[t]; e != nil && e.consts[str] != "" {
+			s = e.consts[str]
+		}
+	case Object:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return g.dynamic(v)
+		}
+		var b strings.Builder
+		switch {
+		case t.Nullable:
+			b.WriteString("&" + g.names[t.Name])
+		case !elide:
+			b.WriteString(g.names[t.Name])
+		}
+		b.WriteString("{\n")
+		base := g.bases[t.Name]
+		if base != nil {
+			// promoted fields cannot be set in a composite literal
+			if s := g.fieldValues(base.fields, obj); s != "" {
+				fmt.Fprintf(&b, "%s: %s{\n%s},\n", base.name, base.name, s)
+			}
+		}
+		var own []goField
+		for _, f := range g.structFields(t) {
+			if base == nil || !base.keys[f.Key] {
+				own = append(own, f)
+			}
+		}
+		b.WriteString(g.fieldValues(own, obj))
+		b.WriteString("}")
+		return b.String()
+	case Array:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return g.dynamic(v)
+		}
+		var b strings.Builder
+		b.WriteString(g.typeExpr(t) + "{")
+		for _, e := range arr {
+			s := g.literal(t.Elem, e, true)
+			if s == "" {
+				return ""
+			}
+			fmt.Fprintf(&b, "\n%s,", s)
+		}
+		if len(arr) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+		return b.String()
+	default:
+		return g.dynamic(v)
+	}
+
+	if t.Nullable {
+		// name the type, untyped constants would make pointers to int
+		g.helpers["ptr"] = true
+		return "ptr[" + strings.TrimPrefix(g.typeExpr(t), "*") + "](" + s + ")"
+	}
+	return s
+}
+
+// fieldValues renders the elements of a struct literal setting fields to
+// the values obj holds for their keys.
+func (g *goWriter) fieldValues(fields []goField, obj map[string]interface{}) string {
+	var b strings.Builder
+	for _, f := range fields {
+		fv, ok := obj[f.Key]
+		if !ok || fv == nil {
+			continue
+		}
+		if s := g.literal(f.Type, fv, false); s != "" {
+			fmt.Fprintf(&b, "%s: %s,\n", f.Name, s)
+		}
+	}
+	return b.String()
+}
+
+// overridden renders v as a value of the Go type typ, which an override
+// chose, if typ is a basic type v converts to or json.RawMessage.
+func (g *goWriter) overridden(typ string, v interface{}) string {
+	switch typ {
+	case "json.RawMessage":
+		b, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return "json.RawMessage(" + strconv.Quote(string(b)) + ")"
+	case "interface{}", "any":
+		return g.dynamic(v)
+	case "string":
+		if s, ok := v.(string); ok {
+			return strconv.Quote(s)
+		}
+	case "[]byte":
+		if s, ok := v.(string); ok {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return "[]byte(" + strconv.Quote(string(b)) + ")"
+			}
+		}
+	case "net.IP":
+		if s, ok := v.(string); ok {
+			return "net.ParseIP(" + strconv.Quote(s) + ")"
+		}
+	case "netip.Addr":
+		if s, ok := v.(string); ok {
+			return "netip.MustParseAddr(" + strconv.Quote(s) + ")"
+		}
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b)
+		}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		if n, ok := parseNumber(v); ok && n.integer != nil {
+			return n.integer.String()
+		}
+	case "float32", "float64":
+		if n, ok := parseNumber(v); ok {
+			return n.text
+		}
+	case "json.Number":
+		if n, ok := parseNumber(v); ok {
+			return "json.Number(" + strconv.Quote(n.text) + ")"
+		}
+	}
+	return ""
+}
+
+// dynamic renders v as encoding/json would decode it into an interface{}.
+func (g *goWriter) dynamic(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case float64, json.Number:
+		if n, ok := parseNumber(v); ok {
+			// encoding/json decodes every number as a float64
+			return "float64(" + strconv.FormatFloat(n.float(), 'f', -1, 64) + ")"
+		}
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		var b strings.Builder
+		b.WriteString("[]interface{}{")
+		for _, e := range v {
+			b.WriteString(g.dynamic(e) + ", ")
+		}
+		b.WriteString("}")
+		return b.String()
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("map[string]interface{}{\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s: %s,\n", strconv.Quote(k), g.dynamic(v[k]))
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+	return "nil"
+}
+
+// timeLiteral renders an RFC 3339 timestamp as a call to time.Date.
+func (g *goWriter) timeLiteral(s string) string {
+	g.imports["time"] = true
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return "time.Time{}"
+	}
+	loc := "time.UTC"
+	if name, offset := t.Zone(); offset != 0 || name != "UTC" {
+		loc = fmt.Sprintf("time.FixedZone(%q, %d)", name, offset)
+	}
+	return fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, %d, %s)",
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func (g *goWriter) writeHelpers(w *bytes.Buffer) {
+	names := make([]string, 0, len(g.helpers))
+	for name := range g.helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "\n%s\n", goHelpers[name])
+	}
+}
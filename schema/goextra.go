@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// extraField names the field of the struct of object type t keeping the
+// keys it does not declare, or returns "" if it has none. Structs get one
+// when opts.Extra or an override asks for it.
+func (g *goWriter) extraField(t *Type) string {
+	if t.Kind != Object || isUnion(t) {
+		return ""
+	}
+	ov := t.Override
+	if ov != nil && ov.Type != "" || !g.opts.Extra && (ov == nil || !ov.Extra) {
+		return ""
+	}
+	name := "Extra"
+	for i := 2; g.fieldNamed([]*Type{t}, name); i++ {
+		name = "Extra" + strconv.Itoa(i)
+	}
+	return name
+}
+
+// declareExtra writes the part of an UnmarshalJSON method for the struct of
+// t, already decoded into v, setting field to the keys the struct does not
+// declare.
+func (g *goWriter) declareExtra(w *bytes.Buffer, t *Type, field string) {
+	w.WriteString("var extra map[string]json.RawMessage\n")
+	w.WriteString("if err := json.Unmarshal(data, &extra); err != nil {\nreturn err\n}\n")
+	if fields := g.structFields(t); len(fields) > 0 {
+		g.imports["strings"] = true
+		var names bytes.Buffer
+		for i, f := range fields {
+			if i > 0 {
+				names.WriteString(", ")
+			}
+			names.WriteString(strconv.Quote(g.jsonName(f)))
+		}
+		// encoding/json matches keys regardless of case as well
+		fmt.Fprintf(w, "for key := range extra {\nfor _, name := range [...]string{%s} {\n", names.Bytes())
+		w.WriteString("if strings.EqualFold(key, name) {\ndelete(extra, key)\nbreak\n}\n}\n}\n")
+	}
+	fmt.Fprintf(w, "v.%s = nil\nif len(extra) > 0 {\nv.%s = extra\n}\n", field, field)
+}
+
+// declareMarshal writes a MarshalJSON method for the struct of t encoding
+// the keys kept in field after its own.
+func (g *goWriter) declareMarshal(w *bytes.Buffer, t *Type, field string) {
+	name := g.names[t.Name]
+	fmt.Fprintf(w, "\n// MarshalJSON encodes %s followed by the keys kept in %s.\n", name, field)
+	fmt.Fprintf(w, "func (v %s) MarshalJSON() ([]byte, error) {\ntype plain %s\n", name, name)
+	fmt.Fprintf(w, "data, err := json.Marshal(plain(v))\nif err != nil || len(v.%s) == 0 {\nreturn data, err\n}\n", field)
+	fmt.Fprintf(w, "extra, err := json.Marshal(v.%s)\nif err != nil {\nreturn nil, err\n}\n", field)
+	w.WriteString("if string(data) == \"{}\" {\nreturn extra, nil\n}\n")
+	w.WriteString("return append(append(data[:len(data)-1], ','), extra[1:]...), nil\n}\n")
+}
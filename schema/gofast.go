@@ -0,0 +1,568 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fastField is a struct field as the generated methods read and write it.
+type fastField struct {
+	goField
+	key       string // the JSON key, as encoding/json names it
+	omitEmpty bool
+	quoted    bool
+}
+
+// fastBasic describes a Go type the reader decodes directly: the reader
+// method, given the bit size, and the type it returns.
+type fastBasic struct {
+	read, bits, typ string
+}
+
+var fastBasics = map[string]fastBasic{
+	"bool":    {"boolean", "", "bool"},
+	"string":  {"str", "", "string"},
+	"int":     {"int", "0", "int64"},
+	"int64":   {"int", "64", "int64"},
+	"int32":   {"int", "32", "int64"},
+	"int16":   {"int", "16", "int64"},
+	"int8":    {"int", "8", "int64"},
+	"uint":    {"uint", "0", "uint64"},
+	"uint64":  {"uint", "64", "uint64"},
+	"uint32":  {"uint", "32", "uint64"},
+	"uint16":  {"uint", "16", "uint64"},
+	"uint8":   {"uint", "8", "uint64"},
+	"float64": {"float", "64", "float64"},
+	"float32": {"float", "32", "float64"},
+}
+
+// findFast picks the structs given generated methods when opts.FastJSON is
+// set: every struct but those encoding/json would read differently, with
+// two fields of one JSON name or an omitempty field whose zero value is not
+// known, which keep their usual methods.
+func (g *goWriter) findFast(root *Type) {
+	if !g.opts.FastJSON {
+		return
+	}
+	for _, o := range declarationOrder(root) {
+		if isUnion(o) {
+			continue
+		}
+		if _, ok := g.fastFields(o); ok {
+			g.fast[o.Name] = true
+		}
+	}
+}
+
+// fastRoot reports whether the root type t, when not a struct, is given
+// generated methods.
+func (g *goWriter) fastRoot(t *Type) bool {
+	return g.opts.FastJSON && (t.Kind == Array || t.Kind == Map) && (t.Override == nil || t.Override.Type == "")
+}
+
+// fastFields lists the fields of the struct of t the methods read and write,
+// embedded ones first as encoding/json writes them. It reports false if
+// they cannot be written as encoding/json does.
+func (g *goWriter) fastFields(t *Type) ([]fastField, bool) {
+	var fields []goField
+	base := g.bases[t.Name]
+	if base != nil {
+		fields = append(fields, base.fields...)
+	}
+	for _, f := range g.structFields(t) {
+		if base == nil || !base.keys[f.Key] {
+			fields = append(fields, f)
+		}
+	}
+	list := make([]fastField, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		tag, ok := reflect.StructTag(g.tag(f)).Lookup("json")
+		if !token.IsExported(f.Name) || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if !ok || name == "" {
+			name = f.Name
+		}
+		ff := fastField{goField: f, key: name}
+		for _, opt := range strings.Split(opts, ",") {
+			ff.omitEmpty = ff.omitEmpty || opt == "omitempty"
+			ff.quoted = ff.quoted || opt == "string"
+		}
+		if seen[name] {
+			return nil, false
+		}
+		seen[name] = true
+		if _, ok := g.nonZero(f.Type, ""); ff.omitEmpty && !ok {
+			return nil, false
+		}
+		list = append(list, ff)
+	}
+	return list, true
+}
+
+// fastKind classifies the Go type of t for the generated methods: a type
+// of fastBasics or an enum ("basic"), "time", a struct with generated
+// methods, a union, "slice", "map", "raw" for json.RawMessage, or "json"
+// for the types left to encoding/json. ptr reports whether the value is
+// held by a pointer, and base is the type without it.
+func (g *goWriter) fastKind(t *Type) (kind, base string, ptr bool) {
+	// only the types written out are imported
+	imports := g.imports
+	g.imports = make(map[string]bool)
+	expr := g.typeExpr(t)
+	g.imports = imports
+
+	base = strings.TrimPrefix(expr, "*")
+	generated := t != nil && (t.Override == nil || t.Override.Type == "")
+	switch {
+	case fastBasics[base].read != "", generated && g.enumOf[t] != nil:
+		return "basic", base, base != expr
+	case base == "time.Time":
+		return "time", base, base != expr
+	case expr == "json.RawMessage":
+		return "raw", expr, false
+	case !generated:
+	case isUnion(t):
+		return "union", expr, false
+	case t.Kind == Object && g.fast[t.Name]:
+		return "struct", base, base != expr
+	case t.Kind == Array:
+		return "slice", expr, false
+	case t.Kind == Map:
+		return "map", expr, false
+	}
+	return "json", expr, false
+}
+
+// basic returns the reader of the basic type base.
+func (g *goWriter) basic(base string) fastBasic {
+	if b, ok := fastBasics[base]; ok {
+		return b
+	}
+	// an enum
+	return fastBasics["string"]
+}
+
+// nonZero returns the condition under which encoding/json writes x, a
+// value of t, to a field tagged omitempty, or "" if it always does. It
+// reports false if the zero value of the type is not known.
+func (g *goWriter) nonZero(t *Type, x string) (string, bool) {
+	kind, base, ptr := g.fastKind(t)
+	switch {
+	case ptr, kind == "union":
+		return x + " != nil", true
+	case kind == "slice", kind == "map", kind == "raw":
+		return "len(" + x + ") != 0", true
+	case kind == "struct", kind == "time":
+		// omitempty leaves no struct out
+		return "", true
+	case kind == "basic":
+		switch g.basic(base).typ {
+		case "bool":
+			return x, true
+		case "string":
+			return x + ` != ""`, true
+		}
+		return x + " != 0", true
+	}
+	switch {
+	case strings.HasPrefix(base, "[]"), strings.HasPrefix(base, "map["), base == "net.IP":
+		return "len(" + x + ") != 0", true
+	case base == "interface{}", base == "any", strings.HasPrefix(base, "*"):
+		return x + " != nil", true
+	case base == "json.Number":
+		return x + ` != ""`, true
+	case base == "netip.Addr", t == nil:
+	case t.Override != nil && t.Override.Type != "":
+	case t.Kind == Object, Conflicting(t) && g.opts.Mixed == MixedUnion:
+		// structs, generated ones and union structs alike
+		return "", true
+	}
+	return "", false
+}
+
+// fastMethod collects the body of one generated method.
+type fastMethod struct {
+	g       *goWriter
+	w       bytes.Buffer
+	usesErr bool // the body assigns to err, which must be declared
+	// ret returns from the method or closure being written, given the
+	// error; readField also reports the key was found.
+	ret string
+}
+
+// fail writes the statement returning the error err.
+func (m *fastMethod) fail(err string) string {
+	if m.ret == "" {
+		return "return " + err
+	}
+	return m.ret + err
+}
+
+// GoJSON renders methods encoding and decoding the Go types of t without
+// reflection, as a second file of the package Go renders with
+// opts.FastJSON set. The file is left out of builds with the jsonreflect
+// tag, so that the types can be measured with encoding/json alone; the
+// methods encoding/json needs then, to choose the variants of unions and
+// keep unknown keys, are in a third file, nil if the types need none.
+func GoJSON(t *Type, opts GoOptions) (fast, slow []byte, err error) {
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	opts.FastJSON = true
+	g := newGoWriter(t, opts)
+	// the types are declared, and their packages imported, by Go
+	g.imports = make(map[string]bool)
+	var body bytes.Buffer
+	if g.fastRoot(t) {
+		g.declareFast(&body, t, opts.Naming.typeName(t.Name))
+	}
+	for _, o := range declarationOrder(t) {
+		if g.fast[o.Name] {
+			g.declareFast(&body, o, g.names[o.Name])
+		}
+	}
+	body.WriteString("\n" + fastSource + "\n")
+	names := make([]string, 0, len(g.helpers))
+	for name := range g.helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&body, "\n%s\n", fastHelpers[name])
+	}
+	for _, p := range fastImports {
+		g.imports[p] = true
+	}
+	if fast, err = g.source(body.Bytes(), "!jsonreflect"); err != nil {
+		return fast, nil, err
+	}
+
+	s := newGoWriter(t, opts)
+	s.imports = make(map[string]bool)
+	body.Reset()
+	for _, o := range declarationOrder(t) {
+		if s.fast[o.Name] {
+			s.declareMethods(&body, o)
+		}
+	}
+	if s.fastRoot(t) && isUnion(t.Elem) {
+		s.declareRootUnmarshal(&body, t)
+	}
+	if body.Len() == 0 {
+		return fast, nil, nil
+	}
+	s.imports["encoding/json"] = true
+	slow, err = s.source(body.Bytes(), "jsonreflect")
+	return fast, slow, err
+}
+
+// declareFast writes the methods of the Go type name declared for t, a
+// struct or the root type.
+func (g *goWriter) declareFast(w *bytes.Buffer, t *Type, name string) {
+	fmt.Fprintf(w, "\n// MarshalJSON encodes %s without reflection.\n", name)
+	fmt.Fprintf(w, "func (v %s) MarshalJSON() ([]byte, error) {\nreturn v.appendJSON(make([]byte, 0, 512))\n}\n", name)
+	fmt.Fprintf(w, "\n// UnmarshalJSON decodes %s without reflection.\n", name)
+	fmt.Fprintf(w, "func (v *%s) UnmarshalJSON(data []byte) error {\nr := jsonReader{data: data}\n", name)
+	w.WriteString("if err := v.readJSON(&r); err != nil {\nreturn err\n}\nreturn r.end()\n}\n")
+
+	m := &fastMethod{g: g}
+	if t.Kind != Object {
+		m.write(t, "*v", false, 0)
+	} else {
+		m.writeStruct(t)
+	}
+	fmt.Fprintf(w, "\n// appendJSON appends %s as encoding/json writes it.\n", name)
+	fmt.Fprintf(w, "func (v *%s) appendJSON(b []byte) ([]byte, error) {\n", name)
+	if m.usesErr {
+		w.WriteString("var err error\n")
+	}
+	w.Write(m.w.Bytes())
+	w.WriteString("return b, nil\n}\n")
+
+	m = &fastMethod{g: g}
+	fmt.Fprintf(w, "\n// readJSON reads %s from r.\n", name)
+	fmt.Fprintf(w, "func (v *%s) readJSON(r *jsonReader) error {\n", name)
+	if t.Kind != Object {
+		m.read(t, "*v", false, 0)
+		w.Write(m.w.Bytes())
+		w.WriteString("return nil\n}\n")
+		return
+	}
+	fields, _ := g.fastFields(t)
+	extra := g.extraField(t)
+	w.WriteString("if r.null() {\nreturn nil\n}\n")
+	if extra != "" {
+		fmt.Fprintf(w, "v.%s = nil\n", extra)
+	}
+	w.WriteString("return r.object(func(key []byte) error {\n")
+	w.WriteString("if ok, err := v.readField(r, key); ok || err != nil {\nreturn err\n}\n")
+	if len(fields) > 0 {
+		var names bytes.Buffer
+		for i, f := range fields {
+			if i > 0 {
+				names.WriteString(", ")
+			}
+			names.WriteString(strconv.Quote(f.key))
+		}
+		w.WriteString("// encoding/json matches keys regardless of case as well\n")
+		fmt.Fprintf(w, "for _, name := range [...]string{%s} {\nif bytes.EqualFold(key, []byte(name)) {\n", names.Bytes())
+		w.WriteString("_, err := v.readField(r, []byte(name))\nreturn err\n}\n}\n")
+	}
+	if extra == "" {
+		w.WriteString("_, err := r.raw()\nreturn err\n})\n}\n")
+	} else {
+		w.WriteString("raw, err := r.raw()\nif err != nil {\nreturn err\n}\n")
+		fmt.Fprintf(w, "if v.%s == nil {\nv.%s = make(map[string]json.RawMessage)\n}\n", extra, extra)
+		fmt.Fprintf(w, "v.%s[string(key)] = append(json.RawMessage(nil), raw...)\nreturn nil\n})\n}\n", extra)
+	}
+
+	fmt.Fprintf(w, "\n// readField reads the field of %s named by key, if there is one.\n", name)
+	fmt.Fprintf(w, "func (v *%s) readField(r *jsonReader, key []byte) (bool, error) {\nswitch string(key) {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(w, "case %s:\n", strconv.Quote(f.key))
+		m = &fastMethod{g: g, ret: "return true, "}
+		m.read(f.Type, "v."+f.Name, f.quoted, 0)
+		w.Write(m.w.Bytes())
+		w.WriteString("return true, nil\n")
+	}
+	w.WriteString("}\nreturn false, nil\n}\n")
+}
+
+// writeStruct writes the fields of the struct of t, held by v, followed by
+// the keys kept in its extra field.
+func (m *fastMethod) writeStruct(t *Type) {
+	fields, _ := m.g.fastFields(t)
+	// once a field may have been left out, whether the next one needs a
+	// comma is only known when the code runs
+	const none, some, unknown = 0, 1, 2
+	written := none
+	comma := func() string {
+		switch written {
+		case some:
+			return ","
+		case unknown:
+			m.w.WriteString("if len(b) > start {\nb = append(b, ',')\n}\n")
+		}
+		return ""
+	}
+	for _, f := range fields {
+		x := "v." + f.Name
+		cond, _ := m.g.nonZero(f.Type, x)
+		if !f.omitEmpty {
+			cond = ""
+		}
+		if cond != "" {
+			fmt.Fprintf(&m.w, "if %s {\n", cond)
+		}
+		key, _ := json.Marshal(f.key)
+		fmt.Fprintf(&m.w, "b = append(b, %s...)\n", goString(comma()+string(key)+":"))
+		m.write(f.Type, x, f.quoted, 0)
+		switch {
+		case cond == "":
+			written = some
+		case written == none:
+			m.w.WriteString("}\n")
+			written = unknown
+		default:
+			m.w.WriteString("}\n")
+		}
+	}
+	if extra := m.g.extraField(t); extra != "" {
+		m.g.imports["sort"] = true
+		m.usesErr = true
+		fmt.Fprintf(&m.w, "if len(v.%s) != 0 {\nkeys := make([]string, 0, len(v.%s))\n", extra, extra)
+		fmt.Fprintf(&m.w, "for k := range v.%s {\nkeys = append(keys, k)\n}\nsort.Strings(keys)\nfor _, k := range keys {\n", extra)
+		if written == none {
+			written = unknown
+		}
+		if comma() != "" {
+			m.w.WriteString("b = append(b, ',')\n")
+		}
+		m.w.WriteString("b = appendString(b, k)\n")
+		fmt.Fprintf(&m.w, "b = append(b, ':')\nif b, err = appendRaw(b, v.%s[k]); err != nil {\nreturn nil, err\n}\n}\n}\n", extra)
+	}
+	body := append([]byte(nil), m.w.Bytes()...)
+	m.w.Reset()
+	m.w.WriteString("b = append(b, '{')\n")
+	if written == unknown {
+		m.w.WriteString("start := len(b)\n")
+	}
+	m.w.Write(body)
+	m.w.WriteString("b = append(b, '}')\n")
+}
+
+// goString quotes s as a Go string literal, raw where it can be.
+func goString(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// operand parenthesizes x, an expression the methods read and write, to
+// index or slice it.
+func operand(x string) string {
+	if strings.HasPrefix(x, "*") {
+		return "(" + x + ")"
+	}
+	return x
+}
+
+// conv converts x of Go type from to type to, if they differ.
+func conv(x, from, to string) string {
+	if from == to {
+		return x
+	}
+	return to + "(" + x + ")"
+}
+
+// write writes the statements appending x, a value of t, to b. quoted
+// writes basic values as strings, for the ",string" tag option, and d is
+// the depth of the value in slices and maps, which numbers the variables.
+func (m *fastMethod) write(t *Type, x string, quoted bool, d int) {
+	g := m.g
+	w := &m.w
+	kind, base, ptr := g.fastKind(t)
+	if ptr {
+		fmt.Fprintf(w, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\n", x)
+		if kind == "basic" || kind == "time" {
+			x = "*" + x
+		}
+		defer w.WriteString("}\n")
+	}
+	if kind == "basic" && quoted {
+		w.WriteString("b = append(b, '\"')\n")
+		defer w.WriteString("b = append(b, '\"')\n")
+	}
+	switch kind {
+	case "basic":
+		b := g.basic(base)
+		switch b.typ {
+		case "bool":
+			fmt.Fprintf(w, "b = strconv.AppendBool(b, %s)\n", x)
+		case "string":
+			if quoted {
+				x = "string(appendString(nil, " + conv(x, base, "string") + "))"
+			}
+			fmt.Fprintf(w, "b = appendString(b, %s)\n", conv(x, base, "string"))
+		case "int64":
+			fmt.Fprintf(w, "b = strconv.AppendInt(b, %s, 10)\n", conv(x, base, "int64"))
+		case "uint64":
+			fmt.Fprintf(w, "b = strconv.AppendUint(b, %s, 10)\n", conv(x, base, "uint64"))
+		case "float64":
+			m.usesErr = true
+			fmt.Fprintf(w, "if b, err = appendFloat(b, %s, %s); err != nil {\nreturn nil, err\n}\n", conv(x, base, "float64"), b.bits)
+		}
+	case "time":
+		m.usesErr = true
+		g.helpers["appendTime"] = true
+		g.imports["time"] = true
+		fmt.Fprintf(w, "if b, err = appendTime(b, %s); err != nil {\nreturn nil, err\n}\n", x)
+	case "struct":
+		m.usesErr = true
+		fmt.Fprintf(w, "if b, err = %s.appendJSON(b); err != nil {\nreturn nil, err\n}\n", x)
+	case "raw":
+		m.usesErr = true
+		fmt.Fprintf(w, "if b, err = appendRaw(b, %s); err != nil {\nreturn nil, err\n}\n", x)
+	case "union":
+		m.usesErr = true
+		u := fmt.Sprintf("u%d", d)
+		fmt.Fprintf(w, "switch %s := %s.(type) {\n", u, x)
+		for _, v := range t.Variants {
+			if g.fast[v.Type.Name] {
+				fmt.Fprintf(w, "case %s:\nif b, err = %s.appendJSON(b); err != nil {\nreturn nil, err\n}\n", g.names[v.Type.Name], u)
+			}
+		}
+		fmt.Fprintf(w, "default:\nif b, err = appendValue(b, %s); err != nil {\nreturn nil, err\n}\n}\n", u)
+	case "slice":
+		i := fmt.Sprintf("i%d", d)
+		fmt.Fprintf(w, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\nb = append(b, '[')\n", x)
+		fmt.Fprintf(w, "for %s := range %s {\nif %s > 0 {\nb = append(b, ',')\n}\n", i, x, i)
+		m.write(t.Elem, operand(x)+"["+i+"]", false, d+1)
+		w.WriteString("}\nb = append(b, ']')\n}\n")
+	case "map":
+		g.imports["sort"] = true
+		keys, i, k, e := fmt.Sprintf("keys%d", d), fmt.Sprintf("i%d", d), fmt.Sprintf("k%d", d), fmt.Sprintf("e%d", d)
+		fmt.Fprintf(w, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\n", x)
+		fmt.Fprintf(w, "%s := make([]string, 0, len(%s))\nfor %s := range %s {\n%s = append(%s, %s)\n}\nsort.Strings(%s)\n", keys, x, k, x, keys, keys, k, keys)
+		fmt.Fprintf(w, "b = append(b, '{')\nfor %s, %s := range %s {\nif %s > 0 {\nb = append(b, ',')\n}\n", i, k, keys, i)
+		fmt.Fprintf(w, "b = appendString(b, %s)\nb = append(b, ':')\n%s := %s[%s]\n", k, e, operand(x), k)
+		m.write(t.Elem, e, false, d+1)
+		w.WriteString("}\nb = append(b, '}')\n}\n")
+	default:
+		m.usesErr = true
+		fmt.Fprintf(w, "if b, err = appendValue(b, %s); err != nil {\nreturn nil, err\n}\n", x)
+	}
+}
+
+// read writes the statements reading x, a value of t, from r, as write
+// writes them.
+func (m *fastMethod) read(t *Type, x string, quoted bool, d int) {
+	g := m.g
+	w := &m.w
+	kind, base, ptr := g.fastKind(t)
+	if ptr {
+		fmt.Fprintf(w, "if r.null() {\n%s = nil\n} else {\nif %s == nil {\n%s = new(%s)\n}\n", x, x, x, g.typeExpr(t)[1:])
+		if kind == "basic" {
+			x = "*" + x
+		}
+		defer w.WriteString("}\n")
+	}
+	fail := m.fail("err")
+	switch kind {
+	case "basic":
+		if !ptr {
+			w.WriteString("if !r.null() {\n")
+			defer w.WriteString("}\n")
+		}
+		b := g.basic(base)
+		args := strconv.FormatBool(quoted)
+		if b.bits != "" {
+			args = b.bits + ", " + args
+		}
+		fmt.Fprintf(w, "val, err := r.%s(%s)\nif err != nil {\n%s\n}\n%s = %s\n", b.read, args, fail, x, conv("val", b.typ, base))
+	case "time":
+		fmt.Fprintf(w, "if raw, err := r.raw(); err != nil {\n%s\n} else if err := %s.UnmarshalJSON(raw); err != nil {\n%s\n}\n", fail, x, fail)
+	case "struct":
+		fmt.Fprintf(w, "if err := %s.readJSON(r); err != nil {\n%s\n}\n", x, fail)
+	case "raw":
+		fmt.Fprintf(w, "raw, err := r.raw()\nif err != nil {\n%s\n}\n%s = append(%s[:0], raw...)\n", fail, x, operand(x))
+	case "union":
+		fmt.Fprintf(w, "if raw, err := r.raw(); err != nil {\n%s\n} else if %s, err = Unmarshal%s(raw); err != nil {\n%s\n}\n", fail, x, g.names[t.Name], fail)
+	case "slice", "map":
+		e := fmt.Sprintf("e%d", d)
+		fmt.Fprintf(w, "if r.null() {\n%s = nil\n} else {\n", x)
+		if kind == "slice" {
+			// grown from 4 elements, as encoding/json does
+			fmt.Fprintf(w, "%s = %s[:0]\nif err := r.array(func() error {\nif %s == nil {\n%s = make(%s, 0, 4)\n}\n", x, operand(x), x, x, base)
+		} else {
+			fmt.Fprintf(w, "if %s == nil {\n%s = make(%s)\n}\nif err := r.object(func(key []byte) error {\n", x, x, base)
+		}
+		fmt.Fprintf(w, "var %s %s\n", e, g.typeExpr(t.Elem))
+		ret := m.ret
+		m.ret = ""
+		m.read(t.Elem, e, false, d+1)
+		m.ret = ret
+		if kind == "slice" {
+			fmt.Fprintf(w, "%s = append(%s, %s)\n", x, x, e)
+		} else {
+			fmt.Fprintf(w, "%s[string(key)] = %s\n", operand(x), e)
+		}
+		fmt.Fprintf(w, "return nil\n}); err != nil {\n%s\n}\n", fail)
+		if kind == "slice" {
+			fmt.Fprintf(w, "if %s == nil {\n%s = %s{}\n}\n", x, x, base)
+		}
+		w.WriteString("}\n")
+	default:
+		fmt.Fprintf(w, "if err := r.value(&%s); err != nil {\n%s\n}\n", x, fail)
+	}
+}
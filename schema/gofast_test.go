@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts GoOptions
+	}{
+		{"go_fast", GoOptions{}},
+		{"go_fast_extra", GoOptions{Extra: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fixture(t)
+			opts := tt.opts
+			opts.Tags, opts.FastJSON = []string{"json"}, true
+			types, err := Go(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fast, _, err := GoJSON(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg := map[string][]byte{"types.go": types}
+			if diags := CheckGo(root, opts, "types_json.go", fast, pkg); len(diags) > 0 {
+				t.Errorf("diagnostics: %v", diags)
+			}
+			golden(t, tt.name, fast)
+		})
+	}
+}
+
+// roundTrip decodes each line of the fixture into a Customer and encodes it
+// again, printing the result.
+const roundTrip = `package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	s := bufio.NewScanner(os.Stdin)
+	for s.Scan() {
+		var v Customer
+		if err := json.Unmarshal(s.Bytes(), &v); err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		b, err := json.Marshal(v)
+		fmt.Println(string(b), err)
+	}
+}
+`
+
+// TestGoJSONRoundTrip builds the generated methods and checks they read and
+// write the fixture, and keys differing in case, as encoding/json does.
+func TestGoJSONRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	input, err := os.ReadFile("testdata/fixture.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input = append(input, `{"ID": 4, "NAME": "Barbara", "Other": true, "status": "open"}`+"\n"...)
+
+	for _, extra := range []bool{false, true} {
+		opts := GoOptions{Tags: []string{"json"}, FastJSON: true, Extra: extra}
+		root := fixture(t)
+		types, err := Go(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fast, slow, err := GoJSON(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		files := map[string][]byte{
+			"go.mod":        []byte("module roundtrip\n\ngo 1.20\n"),
+			"main.go":       []byte(roundTrip),
+			"types.go":      types,
+			"types_json.go": fast,
+		}
+		if slow != nil {
+			files["types_reflect.go"] = slow
+		}
+		for name, src := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var out [2][]byte
+		for i, tags := range []string{"", "jsonreflect"} {
+			cmd := exec.Command(goTool, "run", "-tags", tags, ".")
+			cmd.Dir = dir
+			cmd.Stdin = bytes.NewReader(input)
+			cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+			if out[i], err = cmd.CombinedOutput(); err != nil {
+				t.Fatalf("extra=%v tags=%q: %v\n%s", extra, tags, err, out[i])
+			}
+		}
+		if !bytes.Equal(out[0], out[1]) {
+			t.Errorf("extra=%v: generated methods give\n%s\nencoding/json gives\n%s", extra, out[0], out[1])
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package schema
+
+// fastImports are the packages fastSource uses.
+var fastImports = []string{"bytes", "encoding/json", "fmt", "math", "strconv", "unicode/utf16", "unicode/utf8"}
+
+// fastSource is the reader and the append functions shared by the methods
+// GoJSON writes. They follow encoding/json: strings are written with the
+// characters special in HTML escaped, floats in the same notation, and
+// invalid UTF-8 is replaced when strings are read and written.
+const fastSource = `// jsonReader reads JSON values from data without reflection.
+type jsonReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *jsonReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("json: "+format+" at offset %d", append(args, r.pos)...)
+}
+
+// peek skips white space and returns the next byte, or 0 at the end.
+func (r *jsonReader) peek() byte {
+	for ; r.pos < len(r.data); r.pos++ {
+		switch c := r.data[r.pos]; c {
+		case ' ', '\t', '\n', '\r':
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// end reports anything but white space after the value read.
+func (r *jsonReader) end() error {
+	if r.peek(); r.pos < len(r.data) {
+		return r.errorf("unexpected %q after the value", r.data[r.pos])
+	}
+	return nil
+}
+
+// literal reads the literal s if it comes next.
+func (r *jsonReader) literal(s string) bool {
+	if r.peek() == s[0] && bytes.HasPrefix(r.data[r.pos:], []byte(s)) {
+		r.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// null reads null if it comes next.
+func (r *jsonReader) null() bool {
+	return r.literal("null")
+}
+
+// object reads an object, calling fn with each key and the reader at its
+// value. The key is only valid until fn returns.
+func (r *jsonReader) object(fn func(key []byte) error) error {
+	if r.peek() != '{' {
+		return r.errorf("expected an object")
+	}
+	r.pos++
+	if r.peek() == '}' {
+		r.pos++
+		return nil
+	}
+	for {
+		key, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		if r.peek() != ':' {
+			return r.errorf("expected a colon after an object key")
+		}
+		r.pos++
+		if err := fn(key); err != nil {
+			return err
+		}
+		switch r.peek() {
+		case ',':
+			r.pos++
+		case '}':
+			r.pos++
+			return nil
+		default:
+			return r.errorf("expected a comma or a closing brace")
+		}
+	}
+}
+
+// array reads an array, calling fn with the reader at each element.
+func (r *jsonReader) array(fn func() error) error {
+	if r.peek() != '[' {
+		return r.errorf("expected an array")
+	}
+	r.pos++
+	if r.peek() == ']' {
+		r.pos++
+		return nil
+	}
+	for {
+		if err := fn(); err != nil {
+			return err
+		}
+		switch r.peek() {
+		case ',':
+			r.pos++
+		case ']':
+			r.pos++
+			return nil
+		default:
+			return r.errorf("expected a comma or a closing bracket")
+		}
+	}
+}
+
+// bytes reads a string, returning its bytes unescaped. They alias data
+// unless the string holds escapes or invalid UTF-8.
+func (r *jsonReader) bytes() ([]byte, error) {
+	if r.peek() != '"' {
+		return nil, r.errorf("expected a string")
+	}
+	start := r.pos + 1
+	for i := start; i < len(r.data); i++ {
+		switch c := r.data[i]; {
+		case c == '"':
+			r.pos = i + 1
+			return r.data[start:i], nil
+		case c == '\\', c < ' ', c >= utf8.RuneSelf:
+			return r.unescape(start, i)
+		}
+	}
+	r.pos = len(r.data)
+	return nil, r.errorf("unterminated string")
+}
+
+// unescape reads the rest of a string starting at start whose first i-start
+// bytes need no unescaping, replacing invalid UTF-8 as encoding/json does.
+func (r *jsonReader) unescape(start, i int) ([]byte, error) {
+	b := append(make([]byte, 0, i-start+16), r.data[start:i]...)
+	for i < len(r.data) {
+		c := r.data[i]
+		switch {
+		case c == '"':
+			r.pos = i + 1
+			return b, nil
+		case c < ' ':
+			r.pos = i
+			return nil, r.errorf("invalid character %q in string", c)
+		case c >= utf8.RuneSelf:
+			rn, size := utf8.DecodeRune(r.data[i:])
+			b = utf8.AppendRune(b, rn)
+			i += size
+			continue
+		case c != '\\':
+			b = append(b, c)
+			i++
+			continue
+		}
+		if i+1 >= len(r.data) {
+			break
+		}
+		switch e := r.data[i+1]; e {
+		case '"', '\\', '/':
+			b = append(b, e)
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'u':
+			rn, ok := r.hex(i + 2)
+			if !ok {
+				r.pos = i
+				return nil, r.errorf("invalid escape in string")
+			}
+			i += 6
+			if utf16.IsSurrogate(rn) {
+				if low, ok := r.hex(i + 2); ok && r.data[i] == '\\' && r.data[i+1] == 'u' {
+					if rn = utf16.DecodeRune(rn, low); rn != utf8.RuneError {
+						i += 6
+					}
+				} else {
+					rn = utf8.RuneError
+				}
+			}
+			b = utf8.AppendRune(b, rn)
+			continue
+		default:
+			r.pos = i
+			return nil, r.errorf("invalid escape in string")
+		}
+		i += 2
+	}
+	r.pos = len(r.data)
+	return nil, r.errorf("unterminated string")
+}
+
+// hex reads the four hex digits of a \u escape at i.
+func (r *jsonReader) hex(i int) (rune, bool) {
+	if i+4 > len(r.data) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(r.data[i:i+4]), 16, 16)
+	return rune(n), err == nil
+}
+
+// str reads a string, or a string holding one when quoted.
+func (r *jsonReader) str(quoted bool) (string, error) {
+	b, err := r.bytes()
+	if err != nil || !quoted {
+		return string(b), err
+	}
+	inner := jsonReader{data: b}
+	s, err := inner.str(false)
+	if err == nil {
+		err = inner.end()
+	}
+	return s, err
+}
+
+// number reads a number, or a string holding one when quoted, returning
+// its text.
+func (r *jsonReader) number(quoted bool) ([]byte, error) {
+	if quoted {
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		inner := jsonReader{data: b}
+		n, err := inner.number(false)
+		if err == nil && len(n) != len(b) {
+			err = r.errorf("invalid number %q in string", b)
+		}
+		return n, err
+	}
+	r.peek()
+	start, i := r.pos, r.pos
+	digits := func() bool {
+		j := i
+		for i < len(r.data) && r.data[i] >= '0' && r.data[i] <= '9' {
+			i++
+		}
+		return i > j
+	}
+	if i < len(r.data) && r.data[i] == '-' {
+		i++
+	}
+	ok := i < len(r.data) && r.data[i] == '0'
+	if ok {
+		i++
+	} else {
+		ok = digits()
+	}
+	if ok && i < len(r.data) && r.data[i] == '.' {
+		i++
+		ok = digits()
+	}
+	if ok && i < len(r.data) && (r.data[i] == 'e' || r.data[i] == 'E') {
+		i++
+		if i < len(r.data) && (r.data[i] == '+' || r.data[i] == '-') {
+			i++
+		}
+		ok = digits()
+	}
+	if !ok {
+		return nil, r.errorf("expected a number")
+	}
+	r.pos = i
+	return r.data[start:i], nil
+}
+
+// int reads an integer of the given bit size.
+func (r *jsonReader) int(bits int, quoted bool) (int64, error) {
+	b, err := r.number(quoted)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(string(b), 10, bits)
+	if err != nil {
+		return 0, r.errorf("cannot read %s into int%d", b, bits)
+	}
+	return n, nil
+}
+
+// uint reads an unsigned integer of the given bit size.
+func (r *jsonReader) uint(bits int, quoted bool) (uint64, error) {
+	b, err := r.number(quoted)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(string(b), 10, bits)
+	if err != nil {
+		return 0, r.errorf("cannot read %s into uint%d", b, bits)
+	}
+	return n, nil
+}
+
+// float reads a floating-point number of the given bit size.
+func (r *jsonReader) float(bits int, quoted bool) (float64, error) {
+	b, err := r.number(quoted)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(string(b), bits)
+	if err != nil {
+		return 0, r.errorf("cannot read %s into float%d", b, bits)
+	}
+	return f, nil
+}
+
+// boolean reads true or false, or a string holding one when quoted.
+func (r *jsonReader) boolean(quoted bool) (bool, error) {
+	if quoted {
+		b, err := r.bytes()
+		if err != nil {
+			return false, err
+		}
+		inner := jsonReader{data: b}
+		v, err := inner.boolean(false)
+		if err == nil && inner.pos != len(b) {
+			err = r.errorf("invalid boolean %q in string", b)
+		}
+		return v, err
+	}
+	switch {
+	case r.literal("true"):
+		return true, nil
+	case r.literal("false"):
+		return false, nil
+	}
+	return false, r.errorf("expected true or false")
+}
+
+// raw reads any value, returning its text.
+func (r *jsonReader) raw() ([]byte, error) {
+	r.peek()
+	start := r.pos
+	var err error
+	switch c := r.peek(); {
+	case c == '{':
+		err = r.object(func([]byte) error {
+			_, err := r.raw()
+			return err
+		})
+	case c == '[':
+		err = r.array(func() error {
+			_, err := r.raw()
+			return err
+		})
+	case c == '"':
+		_, err = r.bytes()
+	case c == 't', c == 'f':
+		_, err = r.boolean(false)
+	case c == 'n':
+		if !r.null() {
+			err = r.errorf("expected null")
+		}
+	default:
+		_, err = r.number(false)
+	}
+	return r.data[start:r.pos], err
+}
+
+// value reads any value into v with encoding/json, for the types the
+// generated methods leave to it.
+func (r *jsonReader) value(v interface{}) error {
+	raw, err := r.raw()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString appends s quoted as encoding/json writes strings, with the
+// characters special in HTML escaped.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		rn, size := utf8.DecodeRuneInString(s[i:])
+		if rn == utf8.RuneError && size == 1 || rn == '\u2028' || rn == '\u2029' {
+			b = append(b, s[start:i]...)
+			if rn == utf8.RuneError {
+				b = append(b, "\ufffd"...)
+			} else {
+				b = append(b, '\\', 'u', '2', '0', '2', hexDigits[rn&0xf])
+			}
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// appendFloat appends f as encoding/json writes floats of the given bit
+// size.
+func appendFloat(b []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return b, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+// appendRaw appends the JSON text raw compacted, as encoding/json writes a
+// json.RawMessage, or null if it is empty.
+func appendRaw(b []byte, raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 {
+		return append(b, "null"...), nil
+	}
+	var compact, escaped bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return b, err
+	}
+	json.HTMLEscape(&escaped, compact.Bytes())
+	return append(b, escaped.Bytes()...), nil
+}
+
+// appendValue appends v as encoding/json writes it, for the types the
+// generated methods leave to it.
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return append(b, data...), err
+}
+`
+
+// fastHelpers are written after fastSource when the methods call them.
+var fastHelpers = map[string]string{
+	"appendTime": `// appendTime appends t as time.Time writes itself, without the
+// allocation of its MarshalJSON method.
+func appendTime(b []byte, t time.Time) ([]byte, error) {
+	if y := t.Year(); y < 0 || y > 9999 {
+		_, err := t.MarshalJSON()
+		return b, err
+	}
+	b = append(b, '"')
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	return append(b, '"'), nil
+}`,
+}
@@ -0,0 +1,526 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoOptions controls how Go renders types.
+type GoOptions struct {
+	Package string
+	Tags    []string // struct tag keys, each given the field's key
+	Naming  Naming
+	Enums   EnumOptions
+	Mixed   MixedMode
+	// FormatTypes are the Go types declared for strings of each format.
+	FormatTypes FormatTypes
+	// Extra gives every struct a map keeping the keys it does not declare;
+	// overrides can ask for one for some structs only.
+	Extra bool
+	// FastJSON leaves the methods reading and writing structs to GoJSON,
+	// which declares them without reflection, and has GoTest benchmark them.
+	FastJSON bool
+	// Tests has GoTest check that the sample survives decoding and encoding,
+	// and fuzz the round trip from it.
+	Tests bool
+	// Validate checks values against the constraints the samples showed,
+	// with validate tags or Validate methods.
+	Validate ValidateMode
+	// BaseFields is the fewest fields structs must declare alike to have
+	// them moved to an embedded base struct; 0 leaves structs as they are.
+	BaseFields int
+	// Example is a sample document, declared as the variable Example when
+	// not nil.
+	Example interface{}
+}
+
+type goWriter struct {
+	opts    GoOptions
+	names   map[string]string    // object type name -> Go type name
+	fields  map[string][]goField // object type name -> struct fields
+	imports map[string]bool
+	helpers map[string]bool // helper functions the source calls
+	// warnings records each name that had to be adjusted.
+	warnings []Warning
+	enums    []*goEnum
+	enumOf   map[*Type]*goEnum
+	// mixed holds the union structs of values of conflicting kinds, by
+	// mixedKey.
+	mixed      map[string]*goMixed
+	mixedOrder []*goMixed
+	bases      map[string]*goBase // object type name -> embedded base
+	baseOrder  []*goBase
+	fast       map[string]bool // object type names given generated methods
+	// rules are the constraints on the values of fields, and rootRule on
+	// the elements of an array root; omittable marks the struct fields
+	// sometimes missing, and validated the object type names given a
+	// Validate method.
+	rules        map[*Field]*goRule
+	rootRule     *goRule
+	omittable    map[*Field]bool
+	validated    map[string]bool
+	ruleWarnings []Warning
+}
+
+type goField struct {
+	Name string
+	*Field
+}
+
+func newGoWriter(t *Type, opts GoOptions) *goWriter {
+	g := &goWriter{
+		opts:      opts,
+		names:     make(map[string]string),
+		fields:    make(map[string][]goField),
+		imports:   make(map[string]bool),
+		helpers:   make(map[string]bool),
+		enumOf:    make(map[*Type]*goEnum),
+		mixed:     make(map[string]*goMixed),
+		bases:     make(map[string]*goBase),
+		fast:      make(map[string]bool),
+		rules:     make(map[*Field]*goRule),
+		omittable: make(map[*Field]bool),
+		validated: make(map[string]bool),
+	}
+	used := make(map[string]bool)
+	if opts.Example != nil {
+		// the variable shares the package scope with the types
+		used["Example"] = true
+	}
+	if t.Kind != Object {
+		used[opts.Naming.typeName(t.Name)] = true
+	}
+	for _, o := range declarationOrder(t) {
+		name := opts.Naming.typeName(o.Name)
+		base := name
+		for i := 2; used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		if name != base {
+			g.warn(o.Path, fmt.Sprintf("type name %s is already taken, using %s", base, name))
+		}
+		used[name] = true
+		g.names[o.Name] = name
+	}
+	g.findMixed(t, used)
+	g.findEnums(t)
+	for _, e := range g.enums {
+		used[e.name] = true
+		for _, c := range e.consts {
+			used[c] = true
+		}
+	}
+	g.findRules(t)
+	g.findBases(t, used)
+	g.findFast(t)
+	return g
+}
+
+// GoNaming reports the adjustments Go makes to name the types and fields of
+// t: names taken by another key and keys that cannot be named in a tag.
+func GoNaming(t *Type, opts GoOptions) []Warning {
+	g := newGoWriter(t, opts)
+	for _, o := range declarationOrder(t) {
+		if !isUnion(o) {
+			g.structFields(o)
+		}
+	}
+	return g.warnings
+}
+
+func (g *goWriter) warn(path, msg string) {
+	if path == "" {
+		path = "$"
+	}
+	g.warnings = append(g.warnings, Warning{path, msg})
+}
+
+// Go renders t as Go source declaring a named struct for every object type,
+// starting with the root. Source that does not format is returned unformatted
+// along with the error.
+func Go(t *Type, opts GoOptions) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	g := newGoWriter(t, opts)
+	objects := declarationOrder(t)
+
+	var body bytes.Buffer
+	if t.Kind != Object {
+		decl := "type %s %s"
+		if Conflicting(t) && opts.Mixed == MixedUnion {
+			// an alias keeps the union's methods
+			decl = "type %s = %s"
+		}
+		fmt.Fprintf(&body, "\n"+decl+"\n", opts.Naming.typeName(t.Name), g.typeExpr(t))
+	}
+	for _, o := range objects {
+		g.declare(&body, o)
+	}
+	for _, b := range g.baseOrder {
+		g.declareBase(&body, b)
+	}
+	if t.Kind == Array && t.Override == nil && isUnion(t.Elem) && !g.fastRoot(t) {
+		g.declareRootUnmarshal(&body, t)
+	}
+	if t.Kind == Array && t.Override == nil && opts.Validate.method() && (g.rootRule != nil || g.checks(t.Elem)) {
+		g.declareRootValidate(&body, t)
+	}
+	for _, e := range g.enums {
+		g.declareEnum(&body, e)
+	}
+	for _, m := range g.mixedOrder {
+		g.declareMixed(&body, m)
+	}
+	if opts.Example != nil {
+		if example := g.literal(t, opts.Example, false); example != "" {
+			if t.Kind != Object {
+				example = opts.Naming.typeName(t.Name) + "(" + example + ")"
+			}
+			if isUnion(t) {
+				example = g.names[t.Name] + "(" + example + ")"
+			}
+			fmt.Fprintf(&body, "\nvar Example = %s\n", example)
+			g.writeHelpers(&body)
+		}
+	}
+
+	return g.source(body.Bytes(), "")
+}
+
+// source renders body as a file of opts.Package importing g.imports, built
+// only when constraint, if not "", is satisfied.
+func (g *goWriter) source(body []byte, constraint string) ([]byte, error) {
+	var src bytes.Buffer
+	if constraint != "" {
+		fmt.Fprintf(&src, "//go:build %s\n\n", constraint)
+	}
+	fmt.Fprintf(&src, "package %s\n", g.opts.Package)
+	writeImports(&src, g.imports)
+	src.Write(body)
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		// hand back the source so the caller can point at the problem
+		return src.Bytes(), fmt.Errorf("error formatting: %s, was formatting\n%s", err, src.Bytes())
+	}
+	return formatted, nil
+}
+
+func (g *goWriter) declare(w *bytes.Buffer, t *Type) {
+	if isUnion(t) {
+		g.declareUnion(w, t)
+		return
+	}
+	fmt.Fprintf(w, "\ntype %s struct {\n", g.names[t.Name])
+	base := g.bases[t.Name]
+	if base != nil {
+		w.WriteString(base.name + "\n")
+	}
+	for _, f := range g.structFields(t) {
+		if base != nil && base.keys[f.Key] {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s %s%s\n", f.Name, g.typeExpr(f.Type), tagLiteral(g.tag(f)), formatComment(f))
+	}
+	extra := g.extraField(t)
+	if extra != "" {
+		g.imports["encoding/json"] = true
+		fmt.Fprintf(w, "%s map[string]json.RawMessage `json:\"-\"`\n", extra)
+	}
+	w.WriteString("}\n")
+	if !g.fast[t.Name] {
+		g.declareMethods(w, t)
+	}
+	if g.opts.Validate.method() && g.validated[t.Name] {
+		g.declareValidate(w, t)
+	}
+}
+
+// declareMethods writes the methods encoding/json needs to read and write
+// the struct of object type t: choosing the variants of its unions and
+// keeping the keys it does not declare.
+func (g *goWriter) declareMethods(w *bytes.Buffer, t *Type) {
+	extra := g.extraField(t)
+	if fields := g.unionFields(t); len(fields) > 0 || extra != "" {
+		g.declareUnmarshal(w, t, fields, extra)
+	}
+	if extra != "" {
+		g.declareMarshal(w, t, extra)
+	}
+}
+
+// importType returns the Go type ov names, importing its package.
+func (g *goWriter) importType(ov *Override) string {
+	if ov.Import != "" {
+		g.imports[ov.Import] = true
+	} else if expr, err := parser.ParseExpr(ov.Type); err == nil && qualifier(expr) != "" {
+		g.imports[goStdQualifiers[qualifier(expr)]] = true
+	}
+	return ov.Type
+}
+
+// formatComment notes the format of the strings f holds, directly or as
+// elements.
+func formatComment(f goField) string {
+	t := f.Type
+	for t != nil && (t.Kind == Array || t.Kind == Map) {
+		t = t.Elem
+	}
+	if t == nil || t.Kind != String || textFormats[t.Format] == "" || t.Override != nil && t.Override.Type != "" {
+		return ""
+	}
+	return " // " + textFormats[t.Format]
+}
+
+// tag returns the struct tag of f, without quotes. Values are quoted as
+// reflect.StructTag expects. Tags from an override follow the generated ones,
+// replacing those with the same key.
+func (g *goWriter) tag(f goField) string {
+	ov := f.Override
+	if ov == nil {
+		ov = &Override{}
+	}
+	tags := make([]string, 0, len(g.opts.Tags)+len(ov.Tags))
+	if !ov.ReplaceTags {
+		for _, tag := range g.opts.Tags {
+			if _, ok := ov.Tags[tag]; ok {
+				continue
+			}
+			value := f.Key
+			if tag == "json" && quotes(f.Type) {
+				value += ",string"
+			} else if tag == "json" && value == "-" {
+				// a bare "-" skips the field
+				value = "-,"
+			}
+			tags = append(tags, tag+":"+strconv.Quote(value))
+		}
+		if _, ok := ov.Tags["validate"]; !ok && g.opts.Validate.tags() {
+			if rule := g.ruleTag(g.rules[f.Field], f.Type); rule != "" {
+				tags = append(tags, "validate:"+strconv.Quote(rule))
+			}
+		}
+	}
+	keys := make([]string, 0, len(ov.Tags))
+	for key := range ov.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		tags = append(tags, key+":"+strconv.Quote(ov.Tags[key]))
+	}
+	return strings.Join(tags, " ")
+}
+
+// jsonName returns the name encoding/json will use for f, if its tag has one.
+func (g *goWriter) jsonName(f goField) string {
+	if ov := f.Override; ov != nil {
+		if v, ok := ov.Tags["json"]; ok || ov.ReplaceTags {
+			return strings.Split(v, ",")[0]
+		}
+	}
+	return f.Key
+}
+
+// tagLiteral quotes a struct tag as a raw string unless it holds a backquote.
+func tagLiteral(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// structFields names the Go fields of object type t. A key spelled exactly
+// like its field name keeps it, other keys mapping to a name already taken
+// get a numeric suffix in field order, and keys encoding/json cannot name in
+// a tag are left out.
+func (g *goWriter) structFields(t *Type) []goField {
+	if fields, ok := g.fields[t.Name]; ok {
+		return fields
+	}
+	names := make([]string, len(t.Fields))
+	used := make(map[string]bool)
+	if g.opts.Validate.method() {
+		// a field cannot share the name of a method
+		used["Validate"] = true
+	}
+	for i, f := range t.Fields {
+		names[i] = g.opts.Naming.name(f.Key)
+		if f.Override != nil && f.Override.Name != "" {
+			names[i] = f.Override.Name
+			used[names[i]] = true
+		} else if names[i] == f.Key {
+			used[f.Key] = true
+		}
+	}
+
+	fields := make([]goField, 0, len(t.Fields))
+	for i, f := range t.Fields {
+		path := childPath(t.Path, f.Key)
+		// the empty key would leave the tag naming nothing, so the field
+		// would read the key spelled like its Go name instead
+		if name := g.jsonName(goField{"", f}); name != "" && !validJSONName(name) || name == "" && f.Key == "" {
+			g.warn(path, "the key cannot be named in a json struct tag, the field is left out")
+			continue
+		}
+		name := names[i]
+		method := name == "Validate" && g.opts.Validate.method()
+		if (name != f.Key || method) && (f.Override == nil || f.Override.Name == "") {
+			base := name
+			for n := 2; used[name]; n++ {
+				name = base + strconv.Itoa(n)
+			}
+			if name != base && method {
+				g.warn(path, fmt.Sprintf("field name %s is taken by the Validate method, using %s", base, name))
+			} else if name != base {
+				g.warn(path, fmt.Sprintf("field name %s is already taken, using %s", base, name))
+			}
+			used[name] = true
+		}
+		fields = append(fields, goField{name, f})
+	}
+	g.fields[t.Name] = fields
+	return fields
+}
+
+// typeExpr returns the Go type for t. Nullable values are pointers unless
+// the type already has a nil value.
+func (g *goWriter) typeExpr(t *Type) string {
+	if t == nil {
+		return "interface{}"
+	}
+	if ov := t.Override; ov != nil && ov.Type != "" {
+		return g.importType(ov)
+	}
+
+	var s string
+	switch t.Kind {
+	case Bool:
+		s = "bool"
+	case Int:
+		switch t.Format {
+		case "int32", "uint64":
+			s = t.Format
+		case "bigint":
+			// a nil pointer is null already
+			g.imports["math/big"] = true
+			return "*big.Int"
+		default:
+			s = "int64"
+		}
+	case Float:
+		switch t.Format {
+		case "float":
+			s = "float32"
+		case "decimal":
+			g.imports["encoding/json"] = true
+			s = "json.Number"
+		default:
+			s = "float64"
+		}
+	case String:
+		if ov := g.opts.FormatTypes[t.Format]; ov != nil && t.Format != "" {
+			s = g.importType(ov)
+			if strings.HasPrefix(s, "[]") || strings.HasPrefix(s, "*") {
+				return s
+			}
+			break
+		}
+		switch t.Format {
+		case "date-time":
+			g.imports["time"] = true
+			s = "time.Time"
+		case "byte":
+			return "[]byte"
+		default:
+			s = "string"
+			if e := g.enumOf[t]; e != nil {
+				s = e.name
+			}
+		}
+	case Object:
+		s = g.names[t.Name]
+		if isUnion(t) {
+			// interfaces are nil already
+			return s
+		}
+	case Array:
+		return "[]" + g.typeExpr(t.Elem)
+	case Map:
+		return "map[string]" + g.typeExpr(t.Elem)
+	default:
+		if t.Format == "json" {
+			g.imports["encoding/json"] = true
+			return "json.RawMessage"
+		}
+		if Conflicting(t) {
+			return g.mixedType(t)
+		}
+		return "interface{}"
+	}
+	if t.Nullable {
+		s = "*" + s
+	}
+	return s
+}
+
+// declarationOrder lists the distinct object types reachable from t, each
+// one before the types it refers to. Values of a type given by an override
+// are not looked into, and unions are followed by their variants rather
+// than the types of their merged fields.
+func declarationOrder(t *Type) []*Type {
+	var list []*Type
+	seen := make(map[string]bool)
+	var walk func(*Type)
+	walk = func(t *Type) {
+		if t == nil || t.Override != nil && t.Override.Type != "" {
+			return
+		}
+		switch t.Kind {
+		case Object:
+			if seen[t.Name] {
+				return
+			}
+			seen[t.Name] = true
+			list = append(list, t)
+			if isUnion(t) {
+				for _, v := range t.Variants {
+					walk(v.Type)
+				}
+				return
+			}
+			for _, f := range t.Fields {
+				walk(f.Type)
+			}
+		case Array, Map:
+			walk(t.Elem)
+		}
+	}
+	walk(t)
+	return list
+}
+
+func writeImports(w *bytes.Buffer, imports map[string]bool) {
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	switch len(paths) {
+	case 0:
+	case 1:
+		fmt.Fprintf(w, "\nimport %q\n", paths[0])
+	default:
+		w.WriteString("\nimport (\n")
+		for _, p := range paths {
+			fmt.Fprintf(w, "%q\n", p)
+		}
+		w.WriteString(")\n")
+	}
+}
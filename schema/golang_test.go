@@ -0,0 +1,229 @@
+package schema
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GoOptions
+	}{
+		{"go", GoOptions{}},
+		{"go_enums", GoOptions{Enums: EnumOptions{MaxValues: 10, MinCount: 3, Valid: true}}},
+		{"go_extra", GoOptions{Extra: true}},
+		{"go_validate", GoOptions{Validate: ValidateBoth}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fixture(t)
+			tt.opts.Tags = []string{"json"}
+			out, err := Go(root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diags := CheckGo(root, tt.opts, "types.go", out, nil); len(diags) > 0 {
+				t.Errorf("diagnostics: %v", diags)
+			}
+			golden(t, tt.name, out)
+		})
+	}
+}
+
+// fieldType returns the Go type declared for field name in src.
+func fieldType(src []byte, name string) string {
+	m := regexp.MustCompile(`(?m)^\s*` + name + `\s+(\S+)\s+` + "`").FindSubmatch(src)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+func TestGoTypes(t *testing.T) {
+	tests := []struct {
+		samples string
+		want    string
+	}{
+		{`{"v": 1}`, "int64"},
+		{`{"v": 1.5}`, "float64"},
+		{`{"v": "a"}`, "string"},
+		{`{"v": true}`, "bool"},
+		{`{"v": "2020-01-02T03:04:05Z"}`, "time.Time"},
+		{`{"v": "2020-01-02"}`, "string"}, // encoding/json reads only RFC 3339 into time.Time
+		{`{"v": 1} {"v": null}`, "*int64"},
+		{`{"v": [1, 2]}`, "[]int64"},
+		{`{"v": [{"a": 1}]}`, "[]V"},
+		{`{"v": {"a": 1}}`, "V"},
+		{`{"v": []}`, "[]interface{}"},
+		{`{"v": "a"} {"v": 1}`, "interface{}"},
+	}
+	for _, tt := range tests {
+		out, err := Go(Infer("Root", decode(t, tt.samples)...), GoOptions{Tags: []string{"json"}})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.samples, err)
+		}
+		if got := fieldType(out, "V"); got != tt.want {
+			t.Errorf("%s: V %s, want %s", tt.samples, got, tt.want)
+		}
+	}
+}
+
+func TestGoExample(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples string
+	}{
+		{"object", `{"id": 1, "name": "a", "at": "2020-01-02T03:04:05Z", "tags": ["x"], "owner": {"id": 2}, "note": null}`},
+		{"array", `[{"id": 1}, {"id": 2}]`},
+		{"scalar", `42`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := decode(t, tt.samples)
+			out, err := Go(Infer("Root", samples...), GoOptions{Tags: []string{"json"}, Example: samples[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, "go_example_"+tt.name, out)
+		})
+	}
+}
+
+func TestGoExampleName(t *testing.T) {
+	// a type named Example would clash with the variable
+	samples := decode(t, `{"example": {"a": 1}}`)
+	out, err := Go(Infer("Root", samples...), GoOptions{Tags: []string{"json"}, Example: samples[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fieldType(out, "Example"); got != "Example2" {
+		t.Errorf("field Example has type %s, want Example2", got)
+	}
+}
+
+func TestGoNaming(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  string
+		fields   []string // the json tag of each Go field, in order
+		warnings []string
+	}{
+		{"distinct", `{"a": 1, "b": 2}`, []string{`A int64 json:"a"`, `B int64 json:"b"`}, nil},
+		{"collisions", `{"user_id": 1, "userId": 2, "UserID": 3}`,
+			[]string{`UserID int64 json:"UserID"`, `UserID2 int64 json:"userId"`, `UserID3 int64 json:"user_id"`},
+			[]string{
+				`$.userId: field name UserID is already taken, using UserID2`,
+				`$.user_id: field name UserID is already taken, using UserID3`,
+			}},
+		{"untaggable keys", `{"": 1, "a\"b": 2, "c,d": 3, "e": 4}`,
+			[]string{`E int64 json:"e"`},
+			[]string{
+				`$[""]: the key cannot be named in a json struct tag, the field is left out`,
+				`$["a\"b"]: the key cannot be named in a json struct tag, the field is left out`,
+				`$["c,d"]: the key cannot be named in a json struct tag, the field is left out`,
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Infer("Root", decode(t, tt.samples)...)
+			opts := GoOptions{Tags: []string{"json"}}
+			out, err := Go(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, m := range regexp.MustCompile("(?m)^\t(\\w+)\\s+(\\S+)\\s+`(.*)`$").FindAllSubmatch(out, -1) {
+				fields = append(fields, string(m[1])+" "+string(m[2])+" "+string(m[3]))
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("fields %q, want %q", fields, tt.fields)
+			}
+			var warnings []string
+			for _, w := range GoNaming(root, opts) {
+				warnings = append(warnings, w.String())
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("warnings %q, want %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestGoEnumLimits(t *testing.T) {
+	samples := `{"v": "a"} {"v": "b"} {"v": "a"} {"v": "c"}`
+	tests := []struct {
+		opts EnumOptions
+		want string
+	}{
+		{EnumOptions{}, "string"},
+		{EnumOptions{MaxValues: 3, MinCount: 4}, "RootV"},
+		{EnumOptions{MaxValues: 2, MinCount: 4}, "string"},
+		{EnumOptions{MaxValues: 3, MinCount: 5}, "string"},
+	}
+	for _, tt := range tests {
+		out, err := Go(Infer("Root", decode(t, samples)...), GoOptions{Tags: []string{"json"}, Enums: tt.opts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fieldType(out, "V"); got != tt.want {
+			t.Errorf("%+v: V is %s, want %s", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestGoBases(t *testing.T) {
+	// the fixture's structs share no fields, so these objects do
+	samples := decode(t, `{
+		"user": {"id": 1, "created_at": "2024-01-02T03:04:05Z", "name": "a"},
+		"team": {"id": 2, "created_at": "2024-01-02T03:04:05Z", "size": 3},
+		"org": {"id": 3, "created_at": "2024-01-02T03:04:05Z", "plan": "free"}
+	}`)
+	root := Infer("Root", samples...)
+	opts := GoOptions{Tags: []string{"json"}, BaseFields: 2}
+	out, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+		t.Errorf("diagnostics: %v", diags)
+	}
+	golden(t, "go_bases", out)
+	if w := Verify(root, opts, samples...); len(w) > 0 {
+		t.Errorf("samples do not round trip through the embedding structs: %v", w)
+	}
+
+	// too few shared fields for a base
+	opts.BaseFields = 3
+	if out, _ := Go(root, opts); bytes.Contains(out, []byte("Base")) {
+		t.Errorf("base of fewer than 3 fields:\n%s", out)
+	}
+}
+
+func TestGoExtra(t *testing.T) {
+	// a key named extra takes the field name first
+	root := Infer("Root", decode(t, `{"extra": 1, "inner": {"a": 1}}`)...)
+	o, err := ParseOverrides(`{"$.inner": {"extra": true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Apply(root)
+	opts := GoOptions{Tags: []string{"json"}}
+	out, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+		t.Errorf("diagnostics: %v", diags)
+	}
+	if !bytes.Contains(out, []byte("Extra map[string]json.RawMessage `json:\"-\"`")) {
+		t.Errorf("no Extra field for the overridden struct:\n%s", out)
+	}
+
+	opts.Extra = true
+	if out, _ = Go(root, opts); !bytes.Contains(out, []byte("Extra2 map[string]json.RawMessage")) {
+		t.Errorf("Extra field not renamed past the extra key:\n%s", out)
+	}
+}
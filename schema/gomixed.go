@@ -0,0 +1,232 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MixedMode selects the Go type of values seen with conflicting kinds.
+type MixedMode int
+
+const (
+	// MixedAny declares them interface{}.
+	MixedAny MixedMode = iota
+	// MixedRaw keeps them undecoded as json.RawMessage.
+	MixedRaw
+	// MixedUnion declares a struct with a pointer field per kind seen, such
+	// as IntOrString, that decodes into the field matching the value.
+	MixedUnion
+)
+
+var mixedModes = map[string]MixedMode{
+	"any":   MixedAny,
+	"raw":   MixedRaw,
+	"union": MixedUnion,
+}
+
+// ParseMixedMode looks up a mode by the name used in requests: "any", "raw"
+// or "union".
+func ParseMixedMode(s string) (MixedMode, bool) {
+	m, ok := mixedModes[s]
+	return m, ok
+}
+
+var kindGoTypes = map[Kind]string{
+	Bool:   "bool",
+	Int:    "int64",
+	Float:  "float64",
+	String: "string",
+	Object: "map[string]interface{}",
+	Array:  "[]interface{}",
+}
+
+// goMixed is a union struct holding the values of one set of kinds.
+type goMixed struct {
+	name  string
+	kinds []Kind
+}
+
+// mixedKey identifies the union struct for the values of t.
+func mixedKey(t *Type) string {
+	var b strings.Builder
+	for _, k := range conflictKinds(t) {
+		b.WriteString(k.String() + ",")
+	}
+	return b.String()
+}
+
+// fieldName names the field of a union struct holding values of kind k.
+func (k Kind) fieldName() string {
+	s := k.String()
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// findMixed names a union struct for each set of kinds seen together, when
+// opts.Mixed asks for them. Names already used are avoided.
+func (g *goWriter) findMixed(root *Type, used map[string]bool) {
+	if g.opts.Mixed != MixedUnion {
+		return
+	}
+	seen := make(map[*Type]bool)
+	var walk func(t *Type)
+	walk = func(t *Type) {
+		if t == nil || seen[t] || t.Override != nil && t.Override.Type != "" {
+			return
+		}
+		seen[t] = true
+		if Conflicting(t) {
+			key := mixedKey(t)
+			if g.mixed[key] != nil {
+				return
+			}
+			m := &goMixed{kinds: conflictKinds(t)}
+			names := make([]string, len(m.kinds))
+			for i, k := range m.kinds {
+				names[i] = k.fieldName()
+			}
+			base := strings.Join(names, "Or")
+			m.name = base
+			for i := 2; used[m.name]; i++ {
+				m.name = base + strconv.Itoa(i)
+			}
+			used[m.name] = true
+			g.mixed[key] = m
+			g.mixedOrder = append(g.mixedOrder, m)
+			return
+		}
+		switch t.Kind {
+		case Object:
+			if isUnion(t) {
+				for _, v := range t.Variants {
+					walk(v.Type)
+				}
+				return
+			}
+			for _, f := range t.Fields {
+				walk(f.Type)
+			}
+		case Array, Map:
+			walk(t.Elem)
+		}
+	}
+	walk(root)
+}
+
+// mixedType returns the Go type of t, which took values of conflicting
+// kinds.
+func (g *goWriter) mixedType(t *Type) string {
+	switch g.opts.Mixed {
+	case MixedRaw:
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	case MixedUnion:
+		if m := g.mixed[mixedKey(t)]; m != nil {
+			return m.name
+		}
+	}
+	return "interface{}"
+}
+
+// declareMixed writes a union struct and the methods encoding and decoding
+// it as whichever of its values is set.
+func (g *goWriter) declareMixed(w *bytes.Buffer, m *goMixed) {
+	g.imports["encoding/json"] = true
+	g.imports["fmt"] = true
+	names := make([]string, len(m.kinds))
+	for i, k := range m.kinds {
+		names[i] = k.String()
+	}
+	last := len(names) - 1
+	fmt.Fprintf(w, "\n// %s holds a value that was seen as %s or %s; at most one field is set.\n",
+		m.name, strings.Join(names[:last], ", "), names[last])
+	fmt.Fprintf(w, "type %s struct {\n", m.name)
+	for _, k := range m.kinds {
+		fmt.Fprintf(w, "%s %s\n", k.fieldName(), m.fieldType(k))
+	}
+	w.WriteString("}\n")
+
+	fmt.Fprintf(w, "\n// UnmarshalJSON sets the field matching the type of the value.\n")
+	fmt.Fprintf(w, "func (u *%s) UnmarshalJSON(data []byte) error {\n*u = %s{}\nswitch data[0] {\n", m.name, m.name)
+	w.WriteString("case 'n':\nreturn nil\n")
+	number := false
+	for _, k := range m.kinds {
+		switch k {
+		case Bool:
+			fmt.Fprintf(w, "case 't', 'f':\nreturn json.Unmarshal(data, &u.Bool)\n")
+		case String:
+			fmt.Fprintf(w, "case '\"':\nreturn json.Unmarshal(data, &u.String)\n")
+		case Object:
+			fmt.Fprintf(w, "case '{':\nreturn json.Unmarshal(data, &u.Object)\n")
+		case Array:
+			fmt.Fprintf(w, "case '[':\nreturn json.Unmarshal(data, &u.Array)\n")
+		case Int, Float:
+			number = true
+		}
+	}
+	if number {
+		w.WriteString("case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':\n")
+		fmt.Fprintf(w, "return json.Unmarshal(data, &u.%s)\n", m.numberKind().fieldName())
+	}
+	fmt.Fprintf(w, "}\nreturn fmt.Errorf(\"%s: unexpected value %%s\", data)\n}\n", m.name)
+
+	fmt.Fprintf(w, "\n// MarshalJSON encodes the field that is set, or null.\n")
+	fmt.Fprintf(w, "func (u %s) MarshalJSON() ([]byte, error) {\nswitch {\n", m.name)
+	for _, k := range m.kinds {
+		fmt.Fprintf(w, "case u.%s != nil:\nreturn json.Marshal(u.%s)\n", k.fieldName(), k.fieldName())
+	}
+	w.WriteString("}\nreturn []byte(\"null\"), nil\n}\n")
+}
+
+// fieldType returns the Go type of the field holding values of kind k,
+// a pointer unless the type has a nil value already.
+func (m *goMixed) fieldType(k Kind) string {
+	if k == Object || k == Array {
+		return kindGoTypes[k]
+	}
+	return "*" + kindGoTypes[k]
+}
+
+// numberKind returns the kind holding numbers in m.
+func (m *goMixed) numberKind() Kind {
+	for _, k := range m.kinds {
+		if k == Float {
+			return Float
+		}
+	}
+	return Int
+}
+
+// mixedLiteral renders v as a value of the Go type of t, which took values
+// of conflicting kinds.
+func (g *goWriter) mixedLiteral(t *Type, v interface{}) string {
+	switch g.opts.Mixed {
+	case MixedRaw:
+		return g.overridden("json.RawMessage", v)
+	case MixedUnion:
+		m := g.mixed[mixedKey(t)]
+		if m == nil {
+			break
+		}
+		k := kindOf(v)
+		if k == Null {
+			return m.name + "{}"
+		}
+		if k == Int || k == Float {
+			k = m.numberKind()
+		}
+		s := g.dynamic(v)
+		switch k {
+		case Object, Array:
+			return fmt.Sprintf("%s{%s: %s}", m.name, k.fieldName(), s)
+		case Int, Float:
+			if n, ok := parseNumber(v); ok {
+				s = n.text
+			}
+		}
+		g.helpers["ptr"] = true
+		return fmt.Sprintf("%s{%s: ptr[%s](%s)}", m.name, k.fieldName(), kindGoTypes[k], s)
+	}
+	return g.dynamic(v)
+}
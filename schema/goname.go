@@ -0,0 +1,249 @@
+package schema
+
+import (
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameStrategy selects how Go names keys that would not otherwise give an
+// exported identifier: keys starting with a digit or with a letter that has
+// no upper case.
+type NameStrategy int
+
+const (
+	// Transliterate spells letters with accents and Greek and Cyrillic
+	// letters in ASCII, spells out leading numbers and prefixes whatever is
+	// left that cannot be exported.
+	Transliterate NameStrategy = iota
+	// PrefixNames prefixes names that cannot be exported.
+	PrefixNames
+	// SpellNumbers spells out leading numbers and prefixes names that still
+	// cannot be exported.
+	SpellNumbers
+)
+
+var nameStrategies = map[string]NameStrategy{
+	"transliterate": Transliterate,
+	"prefix":        PrefixNames,
+	"spell":         SpellNumbers,
+}
+
+// ParseNameStrategy looks up a strategy by the name used in requests:
+// "transliterate", "prefix" or "spell".
+func ParseNameStrategy(s string) (NameStrategy, bool) {
+	n, ok := nameStrategies[s]
+	return n, ok
+}
+
+// NameStyle selects how Go spells the words of a name.
+type NameStyle int
+
+const (
+	// GoStyle upper cases common initialisms, as golint asks: UserID.
+	GoStyle NameStyle = iota
+	// CamelStyle only capitalizes words: UserId.
+	CamelStyle
+)
+
+var nameStyles = map[string]NameStyle{
+	"go":    GoStyle,
+	"camel": CamelStyle,
+}
+
+// ParseNameStyle looks up a style by the name used in requests: "go" or
+// "camel".
+func ParseNameStyle(s string) (NameStyle, bool) {
+	n, ok := nameStyles[s]
+	return n, ok
+}
+
+// Naming controls how keys become Go identifiers.
+type Naming struct {
+	Strategy NameStrategy
+	Prefix   string // prepended to names that cannot be exported, "X" when empty
+	Style    NameStyle
+	// Initialisms are spelled as given, such as "SKU" or "OAuth", in every
+	// style.
+	Initialisms []string
+}
+
+var goInitialisms = wordSet(`API ASCII CPU CSS DB DNS EOF GUID HTML HTTP HTTPS ID IP
+	JSON LHS NTP QPS RAM RHS RPC SLA SMTP SSH TLS TTL UI UID URI URL UTF8 UUID VM XML`)
+
+// name formats key as an exported Go identifier: words are capitalized, or
+// upper cased when they are initialisms, and joined.
+func (n Naming) name(key string) string {
+	var b strings.Builder
+	for _, w := range words(key) {
+		if n.Strategy == Transliterate {
+			w = transliterate(w)
+		}
+		if w == "" {
+			continue
+		}
+		if s, ok := n.initialism(w); ok {
+			b.WriteString(s)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	name := b.String()
+
+	if n.Strategy != PrefixNames {
+		name = spellLeadingNumber(name)
+	}
+	if !token.IsExported(name) {
+		prefix := n.Prefix
+		if prefix == "" {
+			prefix = "X"
+		}
+		name = prefix + name
+	}
+	return name
+}
+
+// initialism returns the spelling of w if it is an initialism.
+func (n Naming) initialism(w string) (string, bool) {
+	if s, ok := n.custom(w); ok {
+		return s, true
+	}
+	if u := strings.ToUpper(w); n.Style == GoStyle && goInitialisms[u] {
+		return u, true
+	}
+	return "", false
+}
+
+// custom returns the spelling of w if it is one of n.Initialisms.
+func (n Naming) custom(w string) (string, bool) {
+	for _, s := range n.Initialisms {
+		if strings.EqualFold(s, w) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// typeName formats anything but an exported identifier as a field name
+// would be. Exported identifiers keep their spelling but for the words that
+// are configured initialisms and, in CamelStyle, words in capitals.
+func (n Naming) typeName(name string) string {
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return n.name(name)
+	}
+	if strings.Contains(name, "_") {
+		return name
+	}
+	var b strings.Builder
+	for _, w := range splitWords(name) {
+		s, ok := n.custom(w)
+		switch {
+		case ok:
+			b.WriteString(s)
+		case n.Style == CamelStyle && utf8.RuneCountInString(w) > 1 && strings.ToUpper(w) == w:
+			r := []rune(w)
+			b.WriteString(string(r[0]) + strings.ToLower(string(r[1:])))
+		default:
+			b.WriteString(w)
+		}
+	}
+	return b.String()
+}
+
+var digitNames = []string{"Zero", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine",
+	"Ten", "Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+
+var tensNames = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+
+// spellLeadingNumber spells out the number s starts with: "123abc" becomes
+// "OneHundredTwentyThreeAbc". Leading zeros and numbers too long to read
+// are spelled digit by digit.
+func spellLeadingNumber(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return s
+	}
+	digits, rest := s[:i], []rune(s[i:])
+	if len(rest) > 0 {
+		rest[0] = unicode.ToUpper(rest[0])
+	}
+	if n, err := strconv.ParseUint(digits, 10, 64); err == nil && (digits[0] != '0' || len(digits) == 1) && n < 1e12 {
+		return spellNumber(n) + string(rest)
+	}
+	var b strings.Builder
+	for _, d := range digits {
+		b.WriteString(digitNames[d-'0'])
+	}
+	return b.String() + string(rest)
+}
+
+func spellNumber(n uint64) string {
+	switch {
+	case n < 20:
+		return digitNames[n]
+	case n < 100:
+		if n%10 == 0 {
+			return tensNames[n/10]
+		}
+		return tensNames[n/10] + digitNames[n%10]
+	}
+	for _, scale := range []struct {
+		n    uint64
+		name string
+	}{{1e9, "Billion"}, {1e6, "Million"}, {1e3, "Thousand"}, {100, "Hundred"}} {
+		if n >= scale.n {
+			s := spellNumber(n/scale.n) + scale.name
+			if n%scale.n != 0 {
+				s += spellNumber(n % scale.n)
+			}
+			return s
+		}
+	}
+	return ""
+}
+
+// transliterate spells the Latin, Greek and Cyrillic letters of s in ASCII,
+// keeping letters of other scripts.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if t, ok := translit[r]; ok {
+			b.WriteString(t)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var translit = make(map[rune]string)
+
+func init() {
+	// each letter is followed by its spelling; words only hold lower case
+	for _, table := range []string{
+		"à a á a â a ã a ä a å a æ ae ç c è e é e ê e ë e ì i í i î i ï i ð d ñ n " +
+			"ò o ó o ô o õ o ö o ø o ù u ú u û u ü u ý y þ th ÿ y ß ss",
+		"ā a ă a ą a ć c ĉ c ċ c č c ď d đ d ē e ĕ e ė e ę e ě e ĝ g ğ g ġ g ģ g " +
+			"ĥ h ħ h ĩ i ī i ĭ i į i ı i ĳ ij ĵ j ķ k ĺ l ļ l ľ l ŀ l ł l ń n ņ n ň n " +
+			"ŋ ng ō o ŏ o ő o œ oe ŕ r ŗ r ř r ś s ŝ s ş s š s ţ t ť t ŧ t ũ u ū u " +
+			"ŭ u ů u ű u ų u ŵ w ŷ y ź z ż z ž z ș s ț t",
+		"α a β b γ g δ d ε e ζ z η i θ th ι i κ k λ l μ m ν n ξ x ο o π p ρ r " +
+			"σ s ς s τ t υ y φ f χ ch ψ ps ω o ά a έ e ή i ί i ό o ύ y ώ o",
+		"а a б b в v г g д d е e ё yo ж zh з z и i й y к k л l м m н n о o п p " +
+			"р r с s т t у u ф f х kh ц ts ч ch ш sh щ shch ъ  ы y ь  э e ю yu я ya " +
+			"є ye і i ї yi ґ g",
+	} {
+		f := strings.Split(table, " ")
+		for i := 0; i+1 < len(f); i += 2 {
+			r := []rune(f[i])[0]
+			translit[r] = f[i+1]
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package schema
+
+import "testing"
+
+func TestNamingName(t *testing.T) {
+	tests := []struct {
+		naming Naming
+		key    string
+		want   string
+	}{
+		{Naming{}, "user_id", "UserID"},
+		{Naming{}, "html_url", "HTMLURL"},
+		{Naming{}, "café", "Cafe"},
+		{Naming{}, "привет", "Privet"},
+		{Naming{}, "123abc", "OneHundredTwentyThreeAbc"},
+		{Naming{}, "007", "ZeroZeroSeven"},
+		{Naming{}, "名前", "X名前"},
+		{Naming{Strategy: SpellNumbers}, "123abc", "OneHundredTwentyThreeAbc"},
+		{Naming{Strategy: SpellNumbers}, "café", "Café"},
+		{Naming{Strategy: PrefixNames}, "123abc", "X123abc"},
+		{Naming{Strategy: PrefixNames, Prefix: "Field"}, "名前", "Field名前"},
+		{Naming{Style: CamelStyle}, "user_id", "UserId"},
+		{Naming{Style: CamelStyle}, "HTTPServer", "HttpServer"},
+		{Naming{Initialisms: []string{"SKU"}}, "sku_code", "SKUCode"},
+		{Naming{Initialisms: []string{"OAuth"}}, "oauth_token", "OAuthToken"},
+		{Naming{Style: CamelStyle, Initialisms: []string{"SKU"}}, "sku_id", "SKUId"},
+	}
+	for _, tt := range tests {
+		if got := tt.naming.name(tt.key); got != tt.want {
+			t.Errorf("%+v name(%q) = %q, want %q", tt.naming, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSpellLeadingNumber(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1st", "OneSt"},
+		{"20", "Twenty"},
+		{"99Bottles", "NinetyNineBottles"},
+		{"1000", "OneThousand"},
+		{"Abc", "Abc"},
+	}
+	for _, tt := range tests {
+		if got := spellLeadingNumber(tt.in); got != tt.want {
+			t.Errorf("spellLeadingNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// GoTest renders tests of the types Go renders for t, run on sample, a
+// document t was inferred from. With opts.Tests they check that the values
+// of sample survive decoding and encoding and fuzz the round trip from it;
+// with opts.FastJSON they benchmark the methods GoJSON declares, which the
+// jsonreflect tag compares with encoding/json.
+func GoTest(t *Type, opts GoOptions, sample []byte) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	g := &goWriter{opts: opts, imports: map[string]bool{"encoding/json": true, "testing": true}}
+	name := opts.Naming.typeName(t.Name)
+	if t.Kind == Object {
+		// named as Go names it, which can move it off a taken name
+		name = newGoWriter(t, opts).names[t.Name]
+	}
+	if ov := t.Override; ov != nil && ov.Type != "" {
+		name = g.importType(ov)
+	}
+	union := isUnion(t)
+
+	var body bytes.Buffer
+	if opts.FastJSON {
+		body.WriteString("\n// sample is the document the types were generated from. Compare the\n")
+		body.WriteString("// generated methods with encoding/json by running\n//\n")
+		body.WriteString("//\tgo test -bench . -count 10 > fast.txt\n")
+		body.WriteString("//\tgo test -bench . -count 10 -tags jsonreflect > reflect.txt\n")
+		body.WriteString("//\tbenchstat reflect.txt fast.txt\n")
+	} else {
+		body.WriteString("\n// sample is the document the types were generated from.\n")
+	}
+	fmt.Fprintf(&body, "const sample = %s\n", goString(string(sample)))
+	if opts.Tests {
+		g.writeRoundTrip(&body, t, name, union, sample)
+	}
+	if opts.FastJSON {
+		writeBenchmarks(&body, name, union)
+	}
+	return g.source(body.Bytes(), "")
+}
+
+// decodeRoot returns statements declaring v, the root type name decoded
+// from the []byte expression data, that run fail when it does not decode.
+func decodeRoot(name string, union bool, v, data, fail string) string {
+	if union {
+		// a union root is an interface, decoded by its Unmarshal function
+		return fmt.Sprintf("%s, err := Unmarshal%s(%s)\nif err != nil {\n%s\n}\n", v, name, data, fail)
+	}
+	return fmt.Sprintf("var %s %s\nif err := json.Unmarshal(%s, &%s); err != nil {\n%s\n}\n", v, name, data, v, fail)
+}
+
+// writeRoundTrip writes a test decoding sample into the root type name and
+// checking the encoding holds its values, and a fuzz target seeded with it.
+func (g *goWriter) writeRoundTrip(w *bytes.Buffer, t *Type, name string, union bool, sample []byte) {
+	g.imports["bytes"] = true
+	g.imports["fmt"] = true
+	g.imports["math/big"] = true
+	want := "sample"
+	if kept := withoutOmitted(t, sample); kept != nil {
+		want = "want"
+		fmt.Fprintf(w, "\n// want is sample without the keys overrides left out of the types.\nconst want = %s\n", goString(string(kept)))
+	}
+
+	fmt.Fprintf(w, "\n// TestRoundTrip checks that the values of sample survive decoding it into\n// %s and encoding it again.\n", name)
+	w.WriteString("func TestRoundTrip(t *testing.T) {\n")
+	w.WriteString(decodeRoot(name, union, "v", "[]byte(sample)", "t.Fatal(err)"))
+	w.WriteString("out, err := json.Marshal(v)\nif err != nil {\nt.Fatal(err)\n}\n")
+	fmt.Fprintf(w, "checkJSON(t, \"$\", decodeJSON(t, []byte(%s)), decodeJSON(t, out))\n}\n", want)
+
+	fmt.Fprintf(w, "\n// FuzzRoundTrip checks that the documents %s decodes encode to a\n", name)
+	w.WriteString("// document that decodes and encodes again unchanged.\n")
+	w.WriteString("func FuzzRoundTrip(f *testing.F) {\nf.Add([]byte(sample))\nf.Fuzz(func(t *testing.T, data []byte) {\n")
+	w.WriteString(decodeRoot(name, union, "v", "data", "return"))
+	w.WriteString("out, err := json.Marshal(v)\nif err != nil {\nt.Fatalf(\"%s decodes but does not encode: %v\", data, err)\n}\n")
+	w.WriteString(decodeRoot(name, union, "back", "out", `t.Fatalf("%s encodes as %s, which does not decode: %v", data, out, err)`))
+	w.WriteString("again, err := json.Marshal(back)\nif err != nil {\nt.Fatal(err)\n}\n")
+	w.WriteString("if !bytes.Equal(out, again) {\nt.Errorf(\"%s encodes as %s, which encodes as %s\", data, out, again)\n}\n})\n}\n")
+
+	w.WriteString(`
+// decodeJSON decodes the document data, keeping numbers as written.
+func decodeJSON(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// checkJSON reports the values of want, found at path, that got lost or
+// altered. Keys only got has, such as zero values for fields want omits,
+// are allowed, and numbers are compared by value so 1.0 and 1 are the same.
+func checkJSON(t *testing.T, path string, want, got interface{}) {
+	t.Helper()
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: object became %v", path, got)
+			return
+		}
+		for k, v := range w {
+			if _, ok := g[k]; !ok {
+				t.Errorf("%s: lost key %q", path, k)
+				continue
+			}
+			checkJSON(t, path+"."+k, v, g[k])
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			t.Errorf("%s: array of %d elements became %v", path, len(w), got)
+			return
+		}
+		for i := range w {
+			checkJSON(t, fmt.Sprintf("%s[%d]", path, i), w[i], g[i])
+		}
+	case json.Number:
+		g, _ := got.(json.Number)
+		x, _ := new(big.Rat).SetString(string(w))
+		y, ok := new(big.Rat).SetString(string(g))
+		if !ok || x.Cmp(y) != 0 {
+			t.Errorf("%s: %s became %v", path, w, got)
+		}
+	default:
+		if want != got {
+			t.Errorf("%s: %v became %v", path, want, got)
+		}
+	}
+}
+`)
+}
+
+// withoutOmitted returns sample without the keys overrides left out of t,
+// or nil if it has none.
+func withoutOmitted(t *Type, sample []byte) []byte {
+	if len(t.omitted) == 0 {
+		return nil
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(sample))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+	omitted, removed := t.omitted, false
+	var walk func(v interface{}, t *Type, path string)
+	walk = func(v interface{}, t *Type, path string) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if t != nil && t.Kind == Map {
+				for _, e := range v {
+					walk(e, t.Elem, path+"[*]")
+				}
+				return
+			}
+			for k, e := range v {
+				p := childPath(path, k)
+				if omitted[normalPath(p)] {
+					delete(v, k)
+					removed = true
+					continue
+				}
+				var ft *Type
+				if t != nil && t.index[k] != nil {
+					ft = t.index[k].Type
+				}
+				walk(e, ft, p)
+			}
+		case []interface{}:
+			var elem *Type
+			if t != nil {
+				elem = t.Elem
+			}
+			for _, e := range v {
+				walk(e, elem, path+"[]")
+			}
+		}
+	}
+	walk(doc, t, "$")
+	if !removed {
+		return nil
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// writeBenchmarks writes benchmarks of decoding and encoding sample as the
+// root type name.
+func writeBenchmarks(w *bytes.Buffer, name string, union bool) {
+	w.WriteString("\nfunc BenchmarkUnmarshal(b *testing.B) {\ndata := []byte(sample)\n")
+	w.WriteString("b.SetBytes(int64(len(data)))\nb.ReportAllocs()\nfor i := 0; i < b.N; i++ {\n")
+	v := "&v"
+	if union {
+		v = "v"
+		fmt.Fprintf(w, "if _, err := Unmarshal%s(data); err != nil {\nb.Fatal(err)\n}\n}\n}\n", name)
+	} else {
+		fmt.Fprintf(w, "var v %s\nif err := unmarshal(data, &v); err != nil {\nb.Fatal(err)\n}\n}\n}\n", name)
+	}
+	w.WriteString("\nfunc BenchmarkMarshal(b *testing.B) {\n")
+	w.WriteString(decodeRoot(name, union, "v", "[]byte(sample)", "b.Fatal(err)"))
+	w.WriteString("b.SetBytes(int64(len(sample)))\nb.ReportAllocs()\nfor i := 0; i < b.N; i++ {\n")
+	fmt.Fprintf(w, "if _, err := marshal(%s); err != nil {\nb.Fatal(err)\n}\n}\n}\n", v)
+	w.WriteString(`
+// unmarshal decodes data into v with its UnmarshalJSON method, if it has
+// one, skipping the validation json.Unmarshal does first.
+func unmarshal(data []byte, v interface{}) error {
+	if u, ok := v.(json.Unmarshaler); ok {
+		return u.UnmarshalJSON(data)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// marshal encodes v with its MarshalJSON method, if it has one, skipping
+// the validation json.Marshal does after.
+func marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+	return json.Marshal(v)
+}
+`)
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureSample returns the first document of the fixture.
+func fixtureSample(t *testing.T) []byte {
+	t.Helper()
+	f, err := os.Open("testdata/fixture.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Scan()
+	return s.Bytes()
+}
+
+func TestGoTest(t *testing.T) {
+	root := fixture(t)
+	opts := GoOptions{Tags: []string{"json"}, Tests: true}
+	types, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := GoTest(root, opts, fixtureSample(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := map[string][]byte{"types.go": types}
+	if diags := CheckGo(nil, opts, "types_test.go", out, pkg); len(diags) > 0 {
+		t.Errorf("diagnostics: %v", diags)
+	}
+	golden(t, "go_test", out)
+}
+
+// TestGoTestRuns runs the generated tests, and the fuzz target on its seed,
+// against the generated types.
+func TestGoTestRuns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	for _, tt := range []struct{ name, samples string }{
+		{"fixture", ""},
+		{"union", unionDoc},
+		{"array", `[{"id": 1, "tags": ["a"]}, {"id": 2, "tags": null}]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var root *Type
+			var sample []byte
+			if tt.samples == "" {
+				root, sample = fixture(t), fixtureSample(t)
+			} else {
+				samples := decode(t, tt.samples)
+				root, sample = Infer("Root", samples...), []byte(tt.samples)
+				DetectUnions(root, "", samples...)
+			}
+			opts := GoOptions{Tags: []string{"json"}, Tests: true}
+			types, err := Go(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test, err := GoTest(root, opts, sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			for name, src := range map[string][]byte{
+				"go.mod":        []byte("module roundtrip\n\ngo 1.20\n"),
+				"types.go":      types,
+				"types_test.go": test,
+			} {
+				if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cmd := exec.Command(goTool, "test", ".")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("%v\n%s\n%s", err, out, test)
+			}
+		})
+	}
+}
+
+func TestGoTestName(t *testing.T) {
+	// the root gives way to the Example variable, in the tests as well
+	samples := decode(t, `{"a": 1}`)
+	root := Infer("Example", samples...)
+	opts := GoOptions{Tags: []string{"json"}, Tests: true, Example: samples[0]}
+	types, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test, err := GoTest(root, opts, []byte(`{"a": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := map[string][]byte{"types.go": types}
+	if diags := CheckGo(nil, opts, "types_test.go", test, pkg); len(diags) > 0 {
+		t.Errorf("diagnostics: %v\n%s", diags, test)
+	}
+	if !bytes.Contains(test, []byte("var v Example2")) {
+		t.Errorf("tests do not decode into Example2:\n%s", test)
+	}
+}
@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// isUnion reports whether t is declared as an interface implemented by the
+// structs of its variants.
+func isUnion(t *Type) bool {
+	return t != nil && t.Kind == Object && len(t.Variants) > 0 && (t.Override == nil || t.Override.Type == "")
+}
+
+// variant returns the variant of union t that v, one of its objects, is.
+func variant(t *Type, v interface{}) *Type {
+	obj, _ := v.(map[string]interface{})
+	value, _ := obj[t.Discriminator].(string)
+	for _, vt := range t.Variants {
+		if vt.Value == value {
+			return vt.Type
+		}
+	}
+	return nil
+}
+
+// declareUnion writes the interface of union t, the method marking its
+// variants and the function decoding them.
+func (g *goWriter) declareUnion(w *bytes.Buffer, t *Type) {
+	g.imports["encoding/json"] = true
+	g.imports["fmt"] = true
+	name := g.names[t.Name]
+	fmt.Fprintf(w, "\n// %s is one of the objects seen at %s, told apart by their %q key.\n", name, t.Path, t.Discriminator)
+	fmt.Fprintf(w, "type %s interface {\nis%s()\n}\n\n", name, name)
+	for _, v := range t.Variants {
+		fmt.Fprintf(w, "func (%s) is%s() {}\n", g.names[v.Type.Name], name)
+	}
+
+	fmt.Fprintf(w, "\n// Unmarshal%s decodes the %s variant named by the %q key of data.\n", name, name, t.Discriminator)
+	fmt.Fprintf(w, "func Unmarshal%s(data []byte) (%s, error) {\n", name, name)
+	w.WriteString("if string(data) == \"null\" {\nreturn nil, nil\n}\n")
+	fmt.Fprintf(w, "var probe struct {\nValue string `json:%s`\n}\n", strconv.Quote(t.Discriminator))
+	w.WriteString("if err := json.Unmarshal(data, &probe); err != nil {\nreturn nil, err\n}\n")
+	w.WriteString("switch probe.Value {\n")
+	for _, v := range t.Variants {
+		fmt.Fprintf(w, "case %s:\nvar v %s\nerr := json.Unmarshal(data, &v)\nreturn v, err\n", strconv.Quote(v.Value), g.names[v.Type.Name])
+	}
+	fmt.Fprintf(w, "}\nreturn nil, fmt.Errorf(\"unknown %s %s %%q\", probe.Value)\n}\n", name, t.Discriminator)
+}
+
+// unionFields returns the fields of object type t holding unions, or arrays
+// of them, which encoding/json cannot decode on its own.
+func (g *goWriter) unionFields(t *Type) []goField {
+	var list []goField
+	for _, f := range g.structFields(t) {
+		ft := f.Type
+		if ft.Kind == Array && (ft.Override == nil || ft.Override.Type == "") {
+			ft = ft.Elem
+		}
+		if isUnion(ft) {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+// declareUnmarshal writes an UnmarshalJSON method for the struct of object
+// type t decoding the unions its fields hold and keeping the keys it does
+// not declare in extra, if not "". The struct is decoded as usual but for
+// those fields, read as raw messages first.
+func (g *goWriter) declareUnmarshal(w *bytes.Buffer, t *Type, fields []goField, extra string) {
+	name := g.names[t.Name]
+	switch {
+	case len(fields) == 0:
+		fmt.Fprintf(w, "\n// UnmarshalJSON decodes %s, keeping the keys it does not declare in %s.\n", name, extra)
+	case extra == "":
+		fmt.Fprintf(w, "\n// UnmarshalJSON decodes %s, choosing the variants of its unions.\n", name)
+	default:
+		fmt.Fprintf(w, "\n// UnmarshalJSON decodes %s, choosing the variants of its unions and keeping\n// the keys it does not declare in %s.\n", name, extra)
+	}
+	fmt.Fprintf(w, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	if len(fields) == 0 {
+		fmt.Fprintf(w, "type plain %s\nif err := json.Unmarshal(data, (*plain)(v)); err != nil {\nreturn err\n}\n", name)
+		g.declareExtra(w, t, extra)
+		w.WriteString("return nil\n}\n")
+		return
+	}
+	fmt.Fprintf(w, "type plain %s\naux := struct {\n*plain\n", name)
+	for _, f := range fields {
+		tag := ""
+		if jn := g.jsonName(f); jn != "" {
+			tag = " " + tagLiteral("json:"+strconv.Quote(jn))
+		}
+		raw := "json.RawMessage"
+		if !isUnion(f.Type) {
+			raw = "[]" + raw
+		}
+		fmt.Fprintf(w, "%s %s%s\n", f.Name, raw, tag)
+	}
+	w.WriteString("}{plain: (*plain)(v)}\n")
+	w.WriteString("if err := json.Unmarshal(data, &aux); err != nil {\nreturn err\n}\n")
+	for _, f := range fields {
+		if isUnion(f.Type) {
+			fmt.Fprintf(w, "if aux.%s != nil {\nvar err error\n", f.Name)
+			fmt.Fprintf(w, "if v.%s, err = Unmarshal%s(aux.%s); err != nil {\nreturn err\n}\n}\n", f.Name, g.names[f.Type.Name], f.Name)
+			continue
+		}
+		fmt.Fprintf(w, "if aux.%s != nil {\nv.%s = make(%s, len(aux.%s))\n", f.Name, f.Name, g.typeExpr(f.Type), f.Name)
+		fmt.Fprintf(w, "for i, raw := range aux.%s {\nvar err error\n", f.Name)
+		fmt.Fprintf(w, "if v.%s[i], err = Unmarshal%s(raw); err != nil {\nreturn err\n}\n}\n}\n", f.Name, g.names[f.Type.Elem.Name])
+	}
+	if extra != "" {
+		g.declareExtra(w, t, extra)
+	}
+	w.WriteString("return nil\n}\n")
+}
+
+// declareRootUnmarshal writes an UnmarshalJSON method for a root array of
+// unions.
+func (g *goWriter) declareRootUnmarshal(w *bytes.Buffer, t *Type) {
+	name := g.opts.Naming.typeName(t.Name)
+	fmt.Fprintf(w, "\n// UnmarshalJSON decodes %s, choosing the variants of its elements.\n", name)
+	fmt.Fprintf(w, "func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	w.WriteString("var raw []json.RawMessage\nif err := json.Unmarshal(data, &raw); err != nil {\nreturn err\n}\n")
+	w.WriteString("if raw == nil {\n*v = nil\nreturn nil\n}\n")
+	fmt.Fprintf(w, "*v = make(%s, len(raw))\nfor i, r := range raw {\nvar err error\n", name)
+	fmt.Fprintf(w, "if (*v)[i], err = Unmarshal%s(r); err != nil {\nreturn err\n}\n}\nreturn nil\n}\n", g.names[t.Elem.Name])
+}
@@ -0,0 +1,600 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidateMode selects how Go checks values against the constraints the
+// samples showed: which fields were always present, the lengths of strings,
+// the range of numbers and the few values some strings took.
+type ValidateMode int
+
+const (
+	// ValidateNone checks nothing.
+	ValidateNone ValidateMode = iota
+	// ValidateTags writes the constraints as validate struct tags, as
+	// github.com/go-playground/validator reads them.
+	ValidateTags
+	// ValidateMethod declares a Validate method on each struct checking
+	// them, without dependencies.
+	ValidateMethod
+	// ValidateBoth writes the tags and declares the methods.
+	ValidateBoth
+)
+
+var validateModes = map[string]ValidateMode{
+	"none":   ValidateNone,
+	"tags":   ValidateTags,
+	"method": ValidateMethod,
+	"both":   ValidateBoth,
+}
+
+// ParseValidateMode looks up a mode by the name used in requests: "none",
+// "tags", "method" or "both".
+func ParseValidateMode(s string) (ValidateMode, bool) {
+	m, ok := validateModes[s]
+	return m, ok
+}
+
+func (m ValidateMode) tags() bool   { return m == ValidateTags || m == ValidateBoth }
+func (m ValidateMode) method() bool { return m == ValidateMethod || m == ValidateBoth }
+
+// defaultEnums are the thresholds a string field has to meet to be checked
+// against the values it took, when enum detection does not set them.
+var defaultEnums = EnumOptions{MaxValues: 10, MinCount: 3}
+
+// goRule is a constraint on the values of a Go field or element, spelled
+// as the parts of a validate tag.
+type goRule struct {
+	omitEmpty bool     // zero values, such as those of missing keys, pass
+	required  bool     // zero values fail
+	min, max  string   // bounds on numbers, or on the length of strings and slices
+	oneOf     []string // the values strings may take
+	elem      *goRule  // the rule of the elements of a slice, after dive
+	inferred  bool     // from the samples rather than an override
+}
+
+// findRules infers the rule of every struct field from the values merged
+// into the types the struct declares, or reads it from the validate tag an
+// override gives, and marks the structs with anything to check.
+func (g *goWriter) findRules(root *Type) {
+	if g.opts.Validate == ValidateNone {
+		return
+	}
+	// types of much the same shape share a struct, and its rules
+	byName := make(map[string][]*Type)
+	walkTypes(root, func(t *Type) {
+		if t.Kind == Object && !isUnion(t) {
+			byName[t.Name] = append(byName[t.Name], t)
+		}
+	})
+	objects := declarationOrder(root)
+	for _, o := range objects {
+		if isUnion(o) {
+			continue
+		}
+		for _, f := range g.structFields(o) {
+			at := ruleAt{childPath(o.Path, f.Key), g.names[o.Name] + "." + f.Name}
+			if ov := f.Override; ov != nil {
+				if tag, ok := ov.Tags["validate"]; ok {
+					if tag != "" {
+						g.rules[f.Field] = g.parseRule(tag, f.Type, at)
+					}
+					continue
+				} else if ov.ReplaceTags {
+					continue
+				}
+			}
+			var types []*Type
+			optional := false
+			for _, t := range byName[o.Name] {
+				tf := t.index[f.Key]
+				if tf == nil || t.Optional(tf) {
+					optional = true
+				}
+				if tf != nil && tf.Type != nil {
+					types = append(types, tf.Type)
+				}
+			}
+			if optional && f.Type != nil && f.Type.Kind == Object && !strings.HasPrefix(g.typeExpr(f.Type), "*") {
+				// a missing struct is a zero one, with nothing to check
+				g.omittable[f.Field] = true
+			}
+			if r := g.valueRule(f.Type, types, optional, at); r != nil {
+				r.inferred = true
+				g.rules[f.Field] = r
+			}
+		}
+	}
+	if root.Kind == Array && root.Override == nil && root.Elem != nil {
+		at := ruleAt{root.Path, g.opts.Naming.typeName(root.Name)}
+		g.rootRule = g.valueRule(root.Elem, []*Type{root.Elem}, false, at.elem())
+		if g.rootRule != nil {
+			g.rootRule.inferred = true
+		}
+	}
+
+	// a struct holding a struct to check is checked too
+	for changed := true; changed; {
+		changed = false
+		for _, o := range objects {
+			if isUnion(o) || g.validated[o.Name] {
+				continue
+			}
+			for _, f := range g.structFields(o) {
+				if g.rules[f.Field] != nil || g.checks(f.Type) {
+					g.validated[o.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// checks reports whether values of t hold structs with rules to check.
+func (g *goWriter) checks(t *Type) bool {
+	if t == nil || t.Override != nil && t.Override.Type != "" {
+		return false
+	}
+	switch t.Kind {
+	case Object:
+		if isUnion(t) {
+			for _, v := range t.Variants {
+				if g.validated[v.Type.Name] {
+					return true
+				}
+			}
+			return false
+		}
+		return g.validated[t.Name]
+	case Array, Map:
+		return g.checks(t.Elem)
+	}
+	return false
+}
+
+// ruleAt locates a rule in warnings: the path of the values it checks and
+// the Go field holding them, such as ClickEvent.Type, as the variants of a
+// union share their paths.
+type ruleAt struct {
+	path, field string
+}
+
+// elem locates the rule of the elements of the values at a.
+func (a ruleAt) elem() ruleAt {
+	return ruleAt{a.path + "[]", a.field + "[]"}
+}
+
+func (g *goWriter) ruleWarn(at ruleAt, msg string) {
+	g.ruleWarnings = append(g.ruleWarnings, Warning{at.path, at.field + ": " + msg})
+}
+
+// valueRule infers the rule of the values of types at at, which the Go type
+// of rep declares, from what they held. Values of optional fields and of
+// pointers may also be zero. Bounds are only inferred from as many values as
+// enums need, listing the ones skipped.
+func (g *goWriter) valueRule(rep *Type, types []*Type, optional bool, at ruleAt) *goRule {
+	if rep == nil || rep.Override != nil && rep.Override.Type != "" {
+		return nil
+	}
+	nullable := false
+	for _, t := range types {
+		nullable = nullable || t.Nullable
+	}
+	r := &goRule{}
+	enums := g.opts.Enums
+	if enums.MaxValues <= 0 {
+		enums = defaultEnums
+	}
+	switch goType := strings.TrimPrefix(g.typeExpr(rep), "*"); rep.Kind {
+	case String:
+		if goType != "string" && g.enumOf[rep] == nil {
+			// formats declared as other types, such as time.Time
+			return nil
+		}
+		values, count, over := make(map[string]int), 0, false
+		var runes span
+		for _, t := range types {
+			over = over || t.tooMany
+			for v, n := range t.Values {
+				values[v] += n
+				count += n
+			}
+			if t.runes != nil {
+				runes.merge(*t.runes)
+			}
+		}
+		if runes.count == 0 {
+			return nil
+		}
+		if n := len(values); !over && n > 0 && n <= enums.MaxValues && count >= enums.MinCount && n < count && taggable(values) {
+			r.oneOf = sortedCounts(values)
+		} else if runes.max > 0 {
+			min := ""
+			if runes.min > 0 {
+				min = strconv.Itoa(runes.min)
+			}
+			g.bounds(r, at, min, strconv.Itoa(runes.max), runes.count, enums.MinCount)
+		}
+		r.required = !optional && !nullable && runes.min > 0
+	case Int, Float:
+		switch goType {
+		case "int64", "int32", "uint64", "float64":
+		default:
+			// big integers and decimals
+			return nil
+		}
+		var low, high *number
+		count := 0
+		for _, t := range types {
+			if t.num == nil || t.num.low == nil {
+				continue
+			}
+			count += t.num.count
+			if low == nil || t.num.low.value.Cmp(low.value) < 0 {
+				low = t.num.low
+			}
+			if high == nil || t.num.high.value.Cmp(high.value) > 0 {
+				high = t.num.high
+			}
+		}
+		if low == nil {
+			return nil
+		}
+		g.bounds(r, at, boundText(low, rep.Kind), boundText(high, rep.Kind), count, enums.MinCount)
+		r.required = !optional && !nullable && (low.value.Sign() > 0 || high.value.Sign() < 0)
+	case Array:
+		var elems []*Type
+		for _, t := range types {
+			if t.Elem != nil {
+				elems = append(elems, t.Elem)
+			}
+		}
+		r.elem = g.valueRule(rep.Elem, elems, false, at.elem())
+		r.required = !optional && !nullable
+	default:
+		// structs are checked by their own rules
+		return nil
+	}
+	if !r.required && r.min == "" && r.max == "" && r.oneOf == nil && r.elem == nil {
+		return nil
+	}
+	r.omitEmpty = !r.required && (optional || nullable)
+	return r
+}
+
+// bounds sets the min and max of r when they were seen in at least need
+// values, and otherwise lists them at at as skipped.
+func (g *goWriter) bounds(r *goRule, at ruleAt, min, max string, seen, need int) {
+	if seen >= need {
+		r.min, r.max = min, max
+		return
+	}
+	skipped := &goRule{min: min, max: max}
+	g.ruleWarn(at, fmt.Sprintf("validate:%q not inferred, seen in %d of the %d values needed", g.ruleTag(skipped, nil), seen, need))
+}
+
+// boundText spells n as a bound of values of kind, integers without a
+// fraction or exponent.
+func boundText(n *number, kind Kind) string {
+	if kind == Int && n.integer != nil {
+		return n.integer.String()
+	}
+	return n.text
+}
+
+// taggable reports whether the values can be listed in a oneof rule, which
+// separates them with spaces and ends at commas.
+func taggable(values map[string]int) bool {
+	for v := range values {
+		if v == "" || strings.ContainsAny(v, " ,|'\"") {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleTag spells r, the rule of values of t, as a validate tag. Slices of
+// structs with rules to check dive into them even without a rule of their
+// own.
+func (g *goWriter) ruleTag(r *goRule, t *Type) string {
+	var parts []string
+	if r != nil {
+		if r.omitEmpty {
+			parts = append(parts, "omitempty")
+		}
+		if r.required {
+			parts = append(parts, "required")
+		}
+		if r.min != "" {
+			parts = append(parts, "min="+r.min)
+		}
+		if r.max != "" {
+			parts = append(parts, "max="+r.max)
+		}
+		if r.oneOf != nil {
+			parts = append(parts, "oneof="+strings.Join(r.oneOf, " "))
+		}
+	}
+	if t != nil && t.Kind == Map && g.checks(t.Elem) {
+		parts = append(parts, "dive")
+	}
+	if t != nil && t.Kind == Array {
+		var elem *goRule
+		if r != nil {
+			elem = r.elem
+		}
+		if tag := g.ruleTag(elem, t.Elem); tag != "" || g.checks(t.Elem) {
+			parts = append(parts, "dive")
+			if tag != "" {
+				parts = append(parts, tag)
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseRule reads the parts of the validate tag an override gives the field
+// of type t at path that a Validate method can check, warning about the
+// others, which only the tag keeps.
+func (g *goWriter) parseRule(tag string, t *Type, at ruleAt) *goRule {
+	r := &goRule{}
+	parts := strings.Split(tag, ",")
+	for i, part := range parts {
+		key, value, _ := strings.Cut(part, "=")
+		ok := true
+		switch key {
+		case "omitempty":
+			r.omitEmpty = true
+		case "required":
+			r.required = true
+		case "min", "max":
+			if ok = g.validBound(value, t); ok && key == "min" {
+				r.min = value
+			} else if ok {
+				r.max = value
+			}
+		case "oneof":
+			r.oneOf = strings.Fields(value)
+			ok = t != nil && t.Kind == String
+		case "dive":
+			if t == nil || t.Kind != Array {
+				ok = false
+				break
+			}
+			if rest := strings.Join(parts[i+1:], ","); rest != "" {
+				r.elem = g.parseRule(rest, t.Elem, at.elem())
+			}
+			return r
+		default:
+			ok = false
+		}
+		if !ok {
+			g.ruleWarn(at, fmt.Sprintf("Validate does not check %q, only the tag has it", part))
+		}
+	}
+	return r
+}
+
+// validBound reports whether s can bound values of t: their length, for
+// strings and slices, or the numbers themselves.
+func (g *goWriter) validBound(s string, t *Type) bool {
+	if t == nil || t.Override != nil && t.Override.Type != "" {
+		return false
+	}
+	switch t.Kind {
+	case String, Array:
+		n, err := strconv.Atoi(s)
+		return err == nil && n >= 0
+	case Int:
+		switch strings.TrimPrefix(g.typeExpr(t), "*") {
+		case "int64", "int32":
+			_, err := strconv.ParseInt(s, 10, 64)
+			return err == nil
+		case "uint64":
+			_, err := strconv.ParseUint(s, 10, 64)
+			return err == nil
+		}
+	case Float:
+		if strings.TrimPrefix(g.typeExpr(t), "*") == "float64" {
+			_, err := strconv.ParseFloat(s, 64)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// GoValidation lists the rules Go infers for the fields of t, which a
+// validate override loosens, and the parts of override rules its Validate
+// methods do not check.
+func GoValidation(t *Type, opts GoOptions) []Warning {
+	if opts.Validate == ValidateNone {
+		return nil
+	}
+	g := newGoWriter(t, opts)
+	var warnings []Warning
+	for _, o := range declarationOrder(t) {
+		if isUnion(o) {
+			continue
+		}
+		for _, f := range g.structFields(o) {
+			if r := g.rules[f.Field]; r != nil && r.inferred {
+				field := g.names[o.Name] + "." + f.Name
+				warnings = append(warnings, Warning{childPath(o.Path, f.Key), fmt.Sprintf("%s: inferred validate:%q", field, g.ruleTag(r, f.Type))})
+			}
+		}
+	}
+	if r := g.rootRule; r != nil {
+		field := g.opts.Naming.typeName(t.Name) + "[]"
+		warnings = append(warnings, Warning{t.Path + "[]", fmt.Sprintf("%s: inferred validate:%q", field, g.ruleTag(r, t.Elem))})
+	}
+	return append(warnings, g.ruleWarnings...)
+}
+
+// errPath is the location of a value in the messages of a Validate method:
+// a format string and the names of its arguments.
+type errPath struct {
+	format string
+	args   []string
+}
+
+func (p errPath) child(format string, args ...string) errPath {
+	return errPath{p.format + format, append(append([]string(nil), p.args...), args...)}
+}
+
+// errorf returns a call of fmt.Errorf with msg following p, msg having the
+// verbs args fill in.
+func (p errPath) errorf(msg string, args ...string) string {
+	all := append(append([]string(nil), p.args...), args...)
+	if len(all) == 0 {
+		return fmt.Sprintf("fmt.Errorf(%s)", strconv.Quote(p.format+msg))
+	}
+	return fmt.Sprintf("fmt.Errorf(%s, %s)", strconv.Quote(p.format+msg), strings.Join(all, ", "))
+}
+
+// declareValidate writes the Validate method of the struct of object type t.
+func (g *goWriter) declareValidate(w *bytes.Buffer, t *Type) {
+	name := g.names[t.Name]
+	fmt.Fprintf(w, "\n// Validate checks the fields of %s against the rules seen in the samples,\n// reporting the first value that breaks one.\n", name)
+	fmt.Fprintf(w, "func (v %s) Validate() error {\n", name)
+	for _, f := range g.structFields(t) {
+		key := strings.ReplaceAll(f.Key, "%", "%%")
+		if g.omittable[f.Field] && g.checks(f.Type) {
+			g.imports["reflect"] = true
+			fmt.Fprintf(w, "if !reflect.ValueOf(v.%s).IsZero() {\n", f.Name)
+			g.writeCheck(w, g.rules[f.Field], f.Type, "v."+f.Name, errPath{format: key}, 0)
+			w.WriteString("}\n")
+			continue
+		}
+		g.writeCheck(w, g.rules[f.Field], f.Type, "v."+f.Name, errPath{format: key}, 0)
+	}
+	w.WriteString("return nil\n}\n")
+}
+
+// declareRootValidate writes the Validate method of root, an array type.
+func (g *goWriter) declareRootValidate(w *bytes.Buffer, root *Type) {
+	name := g.opts.Naming.typeName(root.Name)
+	fmt.Fprintf(w, "\n// Validate checks the elements of %s against the rules seen in the\n// samples, reporting the first value that breaks one.\n", name)
+	fmt.Fprintf(w, "func (v %s) Validate() error {\n", name)
+	g.writeCheck(w, &goRule{elem: g.rootRule}, root, "v", errPath{}, 0)
+	w.WriteString("return nil\n}\n")
+}
+
+// writeCheck writes statements returning an error when x, a value of type t
+// at p, breaks r or holds a struct whose Validate method fails. d numbers
+// the variables of nested loops.
+func (g *goWriter) writeCheck(w *bytes.Buffer, r *goRule, t *Type, x string, p errPath, d int) {
+	if t == nil || r == nil && !g.checks(t) {
+		return
+	}
+	if r == nil {
+		r = &goRule{}
+	}
+	g.imports["fmt"] = true
+	goType := g.typeExpr(t)
+	if strings.HasPrefix(goType, "*") {
+		if r.required {
+			fmt.Fprintf(w, "if %s == nil {\nreturn %s\n}\n", x, p.errorf(": required"))
+		}
+		fmt.Fprintf(w, "if %s != nil {\n", x)
+		inner := *r
+		inner.required, inner.omitEmpty = false, false
+		if t.Kind != Object {
+			// methods are called through the pointer
+			x = "*" + x
+		}
+		g.writeValueCheck(w, &inner, t, strings.TrimPrefix(goType, "*"), x, p, d)
+		w.WriteString("}\n")
+		return
+	}
+	g.writeValueCheck(w, r, t, goType, x, p, d)
+}
+
+// writeValueCheck writes the checks of writeCheck for x, which is not a
+// pointer, of Go type goType.
+func (g *goWriter) writeValueCheck(w *bytes.Buffer, r *goRule, t *Type, goType, x string, p errPath, d int) {
+	if t.Kind == Object {
+		if isUnion(t) {
+			fmt.Fprintf(w, "if u, ok := %s.(interface{ Validate() error }); ok {\nif err := u.Validate(); err != nil {\nreturn %s\n}\n}\n", x, p.errorf(".%w", "err"))
+		} else if g.validated[t.Name] {
+			fmt.Fprintf(w, "if err := %s.Validate(); err != nil {\nreturn %s\n}\n", x, p.errorf(".%w", "err"))
+		}
+		return
+	}
+	zero := "0"
+	switch t.Kind {
+	case String:
+		zero = `""`
+	case Array, Map:
+		zero = "nil"
+	}
+	if r.required {
+		fmt.Fprintf(w, "if %s == %s {\nreturn %s\n}\n", x, zero, p.errorf(": required"))
+	}
+	if r.omitEmpty {
+		fmt.Fprintf(w, "if %s != %s {\n", x, zero)
+	}
+
+	if r.min != "" || r.max != "" {
+		value, cond, want := x, "", ""
+		switch {
+		case r.min != "" && r.min == r.max:
+			want = "want " + r.min
+		case r.min != "" && r.max != "":
+			want = fmt.Sprintf("want %s to %s", r.min, r.max)
+		case r.min != "":
+			want = "want at least " + r.min
+		default:
+			want = "want at most " + r.max
+		}
+		msg := ": %v, " + want
+		switch t.Kind {
+		case String:
+			g.imports["unicode/utf8"] = true
+			s := x
+			if goType != "string" {
+				s = "string(" + x + ")"
+			}
+			value, msg = "n", ": %d characters, "+want
+			cond = fmt.Sprintf("n := utf8.RuneCountInString(%s); ", s)
+		case Array:
+			value, msg = "n", ": %d elements, "+want
+			cond = fmt.Sprintf("n := len(%s); ", x)
+		}
+		var tests []string
+		if r.min != "" {
+			tests = append(tests, value+" < "+r.min)
+		}
+		if r.max != "" {
+			tests = append(tests, value+" > "+r.max)
+		}
+		fmt.Fprintf(w, "if %s%s {\nreturn %s\n}\n", cond, strings.Join(tests, " || "), p.errorf(msg, value))
+	}
+	if r.oneOf != nil && t.Kind == String {
+		quoted := make([]string, len(r.oneOf))
+		for i, v := range r.oneOf {
+			quoted[i] = strconv.Quote(v)
+		}
+		fmt.Fprintf(w, "switch %s {\ncase %s:\ndefault:\nreturn %s\n}\n", x, strings.Join(quoted, ", "), p.errorf(": %q is not one of "+strings.ReplaceAll(strings.Join(r.oneOf, ", "), "%", "%%"), x))
+	}
+	if t.Kind == Array && (r.elem != nil || g.checks(t.Elem)) {
+		i, e := fmt.Sprintf("i%d", d), fmt.Sprintf("e%d", d)
+		fmt.Fprintf(w, "for %s, %s := range %s {\n", i, e, x)
+		g.writeCheck(w, r.elem, t.Elem, e, p.child("[%d]", i), d+1)
+		w.WriteString("}\n")
+	}
+	if t.Kind == Map && g.checks(t.Elem) {
+		k, e := fmt.Sprintf("k%d", d), fmt.Sprintf("e%d", d)
+		fmt.Fprintf(w, "for %s, %s := range %s {\n", k, e, x)
+		g.writeCheck(w, nil, t.Elem, e, p.child("[%q]", k), d+1)
+		w.WriteString("}\n")
+	}
+
+	if r.omitEmpty {
+		w.WriteString("}\n")
+	}
+}
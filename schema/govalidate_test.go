@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const orderDoc = `
+	{"status": "open", "qty": 2, "note": "a"}
+	{"status": "closed", "qty": 5, "note": "bcd"}
+	{"status": "open", "qty": 3}
+	{"status": "open", "qty": 4, "note": "ef"}
+`
+
+func TestGoValidation(t *testing.T) {
+	root := Infer("Order", decode(t, orderDoc)...)
+	opts := GoOptions{Tags: []string{"json"}, Validate: ValidateTags, Enums: EnumOptions{MaxValues: 10, MinCount: 3}}
+	got := GoValidation(root, opts)
+	want := []string{
+		`$.note: Order.Note: inferred validate:"omitempty,min=1,max=3"`,
+		`$.qty: Order.Qty: inferred validate:"required,min=2,max=5"`,
+		`$.status: Order.Status: inferred validate:"required,oneof=closed open"`,
+	}
+	var lines []string
+	for _, w := range got {
+		lines = append(lines, w.Path+": "+w.Message)
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	// an override replaces the inferred rule, and an empty one drops it
+	o, err := ParseOverrides(`{"$.note": {"tags": {"validate": "max=100"}}, "$.qty": {"tags": {"validate": ""}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Apply(root)
+	out, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`json:"note" validate:"max=100"`, `json:"qty" validate:""`} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("no %s in\n%s", want, out)
+		}
+	}
+	if w := GoValidation(root, opts); len(w) != 1 {
+		t.Errorf("overridden rules still inferred: %v", w)
+	}
+}
+
+func TestGoValidateName(t *testing.T) {
+	root := Infer("Root", decode(t, `{"validate": true}`)...)
+	out, err := Go(root, GoOptions{Tags: []string{"json"}, Validate: ValidateMethod})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fieldType(out, "Validate2"); got != "bool" {
+		t.Errorf("Validate2 is %q in\n%s", got, out)
+	}
+}
+
+// validateMain decodes each line of its input into an Order and prints
+// what Validate says of it.
+const validateMain = `package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	s := bufio.NewScanner(os.Stdin)
+	for s.Scan() {
+		var v Order
+		if err := json.Unmarshal(s.Bytes(), &v); err != nil {
+			panic(err)
+		}
+		fmt.Println(v.Validate())
+	}
+}
+`
+
+func TestGoValidateRuns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	root := Infer("Order", decode(t, orderDoc)...)
+	types, err := Go(root, GoOptions{Tags: []string{"json"}, Validate: ValidateMethod, Enums: EnumOptions{MaxValues: 10, MinCount: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for name, src := range map[string][]byte{
+		"go.mod":   []byte("module validate\n\ngo 1.20\n"),
+		"main.go":  []byte(validateMain),
+		"types.go": types,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := strings.TrimSpace(orderDoc) + "\n" +
+		`{"status": "shipped", "qty": 2}` + "\n" +
+		`{"status": "open", "qty": 9}` + "\n" +
+		`{"status": "open", "qty": 2, "note": "toolong"}` + "\n" +
+		`{"qty": 2}` + "\n"
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s\n%s", err, out, types)
+	}
+	got := strings.Split(strings.TrimSpace(string(out)), "\n")
+	want := []string{"<nil>", "<nil>", "<nil>", "<nil>", "status:", "qty:", "note:", "status: required"}
+	if len(got) != len(want) {
+		t.Fatalf("got\n%s", out)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("line %d: got %q, want %q...", i+1, got[i], want[i])
+		}
+	}
+}
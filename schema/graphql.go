@@ -0,0 +1,175 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// gqlScalars are the custom scalars GraphQL may declare, by the string
+// format or kind they stand for.
+var gqlScalars = map[string]string{
+	"date-time": "DateTime",
+	"date":      "Date",
+	"uuid":      "UUID",
+	"uri":       "URL",
+	"email":     "EmailAddress",
+	"ipv4":      "IPv4",
+	"ipv6":      "IPv6",
+	"byte":      "Byte",
+	"any":       "JSON",
+}
+
+var gqlReserved = map[string]bool{
+	"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true,
+	"DateTime": true, "Date": true, "JSON": true, "UUID": true, "URL": true,
+	"EmailAddress": true, "IPv4": true, "IPv6": true, "Byte": true,
+	"Query": true, "Mutation": true, "Subscription": true,
+}
+
+type gqlWriter struct {
+	types   map[string]string // object type name -> GraphQL type name
+	scalars map[string]bool   // custom scalars referenced
+}
+
+// GraphQL renders the object types of t as GraphQL SDL. Fields present in
+// every object and never null are non-null, timestamps and other string
+// formats use custom scalars, and fields are camelCased with a description
+// naming the original key.
+func GraphQL(t *Type) ([]byte, error) {
+	g := &gqlWriter{types: make(map[string]string), scalars: make(map[string]bool)}
+
+	var objects []*Type
+	used := make(map[string]bool)
+	for _, o := range Objects(t) {
+		if len(o.Fields) == 0 {
+			continue
+		}
+		name := gqlName(o.Name, true)
+		if gqlReserved[name] {
+			name += "Object"
+		}
+		base := name
+		for i := 2; used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		used[name] = true
+		g.types[o.Name] = name
+		objects = append(objects, o)
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("GraphQL output needs an object with at least one field")
+	}
+
+	var body bytes.Buffer
+	for _, o := range objects {
+		fmt.Fprintf(&body, "\ntype %s {\n", g.types[o.Name])
+		names := make(map[string]bool)
+		for _, f := range o.Fields {
+			name := gqlName(f.Key, false)
+			base := name
+			for i := 2; names[name]; i++ {
+				name = base + strconv.Itoa(i)
+			}
+			names[name] = true
+
+			typ := g.typeRef(f.Type, f.Key == "id")
+			if !o.Optional(f) && !f.Type.Nullable {
+				typ += "!"
+			}
+			if name != f.Key {
+				fmt.Fprintf(&body, "  %s\n", strconv.Quote("JSON key: "+f.Key))
+			}
+			fmt.Fprintf(&body, "  %s: %s\n", name, typ)
+		}
+		body.WriteString("}\n")
+	}
+
+	var out bytes.Buffer
+	for _, s := range sortedKeys(g.scalars) {
+		fmt.Fprintf(&out, "scalar %s\n", s)
+	}
+	if out.Len() == 0 {
+		body.Next(1)
+	}
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// typeRef names the GraphQL type of t, without its own non-null marker.
+func (g *gqlWriter) typeRef(t *Type, id bool) string {
+	if t == nil {
+		return g.scalar("any")
+	}
+	switch t.Kind {
+	case Bool:
+		return "Boolean"
+	case Int:
+		if id {
+			return "ID"
+		}
+		return "Int"
+	case Float:
+		return "Float"
+	case String:
+		if id {
+			return "ID"
+		}
+		if _, ok := gqlScalars[t.Format]; ok {
+			return g.scalar(t.Format)
+		}
+		return "String"
+	case Object:
+		if name, ok := g.types[t.Name]; ok {
+			return name
+		}
+	case Array:
+		elem := g.typeRef(t.Elem, false)
+		if t.Elem != nil && !t.Elem.Nullable {
+			elem += "!"
+		}
+		return "[" + elem + "]"
+	}
+	return g.scalar("any")
+}
+
+func (g *gqlWriter) scalar(format string) string {
+	name := gqlScalars[format]
+	g.scalars[name] = true
+	return name
+}
+
+// gqlName converts s to a GraphQL name, PascalCase for types and camelCase
+// for fields. GraphQL names are limited to ASCII letters, digits and
+// underscores.
+func gqlName(s string, upper bool) string {
+	var b strings.Builder
+	for i, w := range words(s) {
+		w = strings.Map(func(r rune) rune {
+			if r > unicode.MaxASCII {
+				return -1
+			}
+			return r
+		}, w)
+		if w == "" {
+			continue
+		}
+		if i > 0 || upper {
+			w = strings.ToUpper(w[:1]) + w[1:]
+		}
+		b.WriteString(w)
+	}
+
+	name := b.String()
+	switch {
+	case name == "" && upper:
+		return "Object"
+	case name == "":
+		return "field"
+	case name[0] >= '0' && name[0] <= '9':
+		return "_" + name
+	}
+	return name
+}
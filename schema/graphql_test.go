@@ -0,0 +1,11 @@
+package schema
+
+import "testing"
+
+func TestGraphQL(t *testing.T) {
+	sdl, err := GraphQL(fixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "graphql", sdl)
+}
@@ -0,0 +1,238 @@
+package schema
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// namer hands out object type names, reusing a name for objects of the same
+// shape and qualifying it with the parent's name when shapes differ.
+type namer struct {
+	taken map[string]string // name -> signature
+	seen  map[*Type]bool
+}
+
+func nameTypes(root *Type, name string) {
+	n := namer{taken: make(map[string]string), seen: make(map[*Type]bool)}
+	if root.Kind != Object {
+		root.Name = name
+	}
+	if root.Kind == Array {
+		n.visit(root.Elem, name+"Item", "")
+		return
+	}
+	n.visit(root, name, "")
+}
+
+func (n namer) visit(t *Type, base, parent string) {
+	if t == nil || n.seen[t] {
+		return
+	}
+	n.seen[t] = true
+	switch t.Kind {
+	case Object:
+		t.Name = n.unique(base, parent, signature(t))
+		for _, v := range t.Variants {
+			n.visit(v.Type, Naming{}.name(v.Value)+t.Name, t.Name)
+		}
+		for _, f := range t.Fields {
+			n.visit(f.Type, Naming{}.name(f.Key), t.Name)
+		}
+	case Array, Map:
+		n.visit(t.Elem, singular(base), parent)
+	}
+}
+
+func (n namer) unique(base, parent, sig string) string {
+	if base == "" || base == "_" {
+		base = "Object"
+	}
+	candidates := []string{base}
+	if parent != "" {
+		candidates = append(candidates, parent+base)
+	}
+	for _, c := range candidates {
+		if s, ok := n.taken[c]; !ok || s == sig {
+			n.taken[c] = sig
+			return c
+		}
+	}
+	for i := 2; ; i++ {
+		c := candidates[len(candidates)-1] + strconv.Itoa(i)
+		if s, ok := n.taken[c]; !ok || s == sig {
+			n.taken[c] = sig
+			return c
+		}
+	}
+}
+
+// signature describes the shape of t, ignoring names.
+func signature(t *Type) string {
+	return shape(t, make(map[*Type]int))
+}
+
+// shape describes t, referring to the enclosing objects in open, by their
+// depth, rather than describing them again.
+func shape(t *Type, open map[*Type]int) string {
+	if t == nil {
+		return "-"
+	}
+	if depth, ok := open[t]; ok {
+		return "^" + strconv.Itoa(len(open)-depth)
+	}
+	var b strings.Builder
+	b.WriteString(t.Kind.String())
+	if t.Format != "" {
+		b.WriteString("(" + t.Format + ")")
+	}
+	if t.Quoted {
+		b.WriteString(",string")
+	}
+	if t.Nullable {
+		b.WriteByte('?')
+	}
+	b.WriteString(t.Override.signature())
+	if t.Kind == Any && len(t.Seen) > 1 {
+		for _, k := range conflictKinds(t) {
+			b.WriteString("|" + k.String())
+		}
+	}
+	switch t.Kind {
+	case Object:
+		open[t] = len(open)
+		defer delete(open, t)
+		b.WriteByte('{')
+		for _, f := range t.Fields {
+			b.WriteString(f.Key)
+			if t.Optional(f) {
+				b.WriteByte('?')
+			}
+			b.WriteString(f.Override.signature())
+			b.WriteByte(':')
+			b.WriteString(shape(f.Type, open))
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+		if t.Discriminator != "" {
+			b.WriteString("|" + t.Discriminator)
+			for _, v := range t.Variants {
+				b.WriteString("|" + v.Value + "=" + shape(v.Type, open))
+			}
+		}
+	case Array, Map:
+		b.WriteByte('[')
+		b.WriteString(shape(t.Elem, open))
+		b.WriteByte(']')
+	}
+	return b.String()
+}
+
+// Objects lists the distinct object types reachable from t, each one after
+// the types it refers to.
+func Objects(t *Type) []*Type {
+	var list []*Type
+	seen := make(map[string]bool)
+	var walk func(*Type)
+	walk = func(t *Type) {
+		if t == nil {
+			return
+		}
+		switch t.Kind {
+		case Object:
+			if seen[t.Name] {
+				return
+			}
+			seen[t.Name] = true
+			for _, f := range t.Fields {
+				walk(f.Type)
+			}
+			list = append(list, t)
+		case Array, Map:
+			walk(t.Elem)
+		}
+	}
+	walk(t)
+	return list
+}
+
+// singularUs are common words ending in "us" that are already singular,
+// unlike plurals of words ending in "u" such as "menus" and "skus".
+var singularUs = map[string]bool{
+	"abacus": true, "alumnus": true, "apparatus": true, "bonus": true,
+	"bus": true, "cactus": true, "campus": true, "census": true,
+	"chorus": true, "circus": true, "consensus": true, "corpus": true,
+	"focus": true, "fungus": true, "genus": true, "hiatus": true,
+	"impetus": true, "locus": true, "minus": true, "nexus": true,
+	"nucleus": true, "octopus": true, "onus": true, "plus": true,
+	"prospectus": true, "radius": true, "sinus": true, "stimulus": true,
+	"status": true, "syllabus": true, "terminus": true, "thesaurus": true,
+	"virus": true, "walrus": true,
+}
+
+// singular makes a best effort to name one element of a plural collection.
+func singular(s string) string {
+	last := ""
+	if list := words(s); len(list) > 0 {
+		last = list[len(list)-1]
+	}
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "ss"), strings.HasSuffix(s, "is"), strings.HasSuffix(last, "ous"), singularUs[last]:
+		return s
+	case strings.HasSuffix(s, "s") && len(s) > 1:
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// words splits an identifier on case changes and non alphanumerics, lower
+// casing each word.
+func words(s string) []string {
+	list := splitWords(s)
+	for i, w := range list {
+		list[i] = strings.ToLower(w)
+	}
+	return list
+}
+
+// splitWords splits an identifier on case changes and non alphanumerics.
+func splitWords(s string) []string {
+	var list []string
+	var cur []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(cur) > 0 {
+			list = append(list, string(cur))
+			cur = cur[:0]
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			flush()
+		case unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && len(cur) > 0 && unicode.IsUpper(cur[len(cur)-1]):
+			// the last capital of an initialism starts the next word: "HTTPServer"
+			flush()
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return list
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
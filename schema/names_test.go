@@ -0,0 +1,57 @@
+package schema
+
+import "testing"
+
+func TestSingular(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"items", "item"},
+		{"Items", "Item"},
+		{"categories", "category"},
+		{"addresses", "address"},
+		{"classes", "class"},
+		{"skus", "sku"},
+		{"SKUs", "SKU"},
+		{"menus", "menu"},
+		{"status", "status"},
+		{"OrderStatus", "OrderStatus"},
+		{"bonus", "bonus"},
+		{"campus", "campus"},
+		{"various", "various"},
+		{"analysis", "analysis"},
+		{"access", "access"},
+		{"data", "data"},
+		{"s", "s"},
+	}
+	for _, tt := range tests {
+		if got := singular(tt.in); got != tt.want {
+			t.Errorf("singular(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNameTypes(t *testing.T) {
+	// objects of one shape share a name, others sharing a key are told apart
+	root := Infer("Root", decode(t, `{
+		"line_items": [{"sku": "a"}],
+		"billing": {"address": {"city": "a", "zip": "1"}},
+		"shipping": {"address": {"city": "b", "zip": "2"}},
+		"office": {"address": {"street": "c"}}
+	}`)...)
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{nil, "Root"},
+		{[]string{"line_items", "[]"}, "LineItem"},
+		{[]string{"billing", "address"}, "Address"},
+		{[]string{"shipping", "address"}, "Address"},
+		{[]string{"office", "address"}, "OfficeAddress"},
+	}
+	for _, tt := range tests {
+		if got := typeAt(root, tt.keys...).Name; got != tt.want {
+			t.Errorf("%v: name %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// numberPrec is the precision numbers are compared at, enough for any
+// number a float64 or a 64-bit integer holds.
+const numberPrec = 2048
+
+var (
+	minInt64  = big.NewInt(math.MinInt64)
+	maxInt64  = big.NewInt(math.MaxInt64)
+	maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+	minInt32  = big.NewInt(math.MinInt32)
+	maxInt32  = big.NewInt(math.MaxInt32)
+)
+
+// numbers records the range of the integers merged into a type, the least
+// and greatest of all its numbers, how many there were and the first number
+// a float64 cannot hold exactly.
+type numbers struct {
+	min, max  *big.Int
+	low, high *number
+	count     int
+	inexact   string
+}
+
+// number is a JSON number: decoded with UseNumber as a json.Number holding
+// its text, or as a float64.
+type number struct {
+	text    string
+	integer *big.Int   // nil unless the number is a whole number
+	value   *big.Float // the number, at numberPrec
+	exact   bool       // a float64 holds the number exactly
+}
+
+// parseNumber reads v, which is a json.Number or a float64.
+func parseNumber(v interface{}) (number, bool) {
+	switch v := v.(type) {
+	case float64:
+		n := number{text: strconv.FormatFloat(v, 'f', -1, 64), exact: true}
+		n.value = new(big.Float).SetPrec(numberPrec).SetFloat64(v)
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			n.integer, _ = new(big.Float).SetFloat64(v).Int(nil)
+		}
+		return n, true
+	case json.Number:
+		n := number{text: string(v)}
+		exact, ok := new(big.Float).SetPrec(numberPrec).SetString(n.text)
+		if !ok {
+			return n, false
+		}
+		n.value = exact
+		// encoding/json reads 1.0 and 1e3 only into floats, whole or not
+		if exact.IsInt() && !strings.ContainsAny(n.text, ".eE") {
+			n.integer, _ = exact.Int(nil)
+		}
+		// a float64 holds the number when its shortest form reads the same
+		f, err := strconv.ParseFloat(n.text, 64)
+		if err == nil {
+			short, _ := new(big.Float).SetPrec(numberPrec).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+			n.exact = short.Cmp(exact) == 0
+		}
+		return n, true
+	}
+	return number{}, false
+}
+
+// float returns n as a float64, rounding it if need be.
+func (n number) float() float64 {
+	f, _ := strconv.ParseFloat(n.text, 64)
+	return f
+}
+
+// addNumber merges n into t.
+func (t *Type) addNumber(n number) {
+	if t.num == nil {
+		t.num = &numbers{}
+	}
+	t.num.count++
+	if !n.exact && t.num.inexact == "" {
+		t.num.inexact = n.text
+	}
+	if t.num.low == nil || n.value.Cmp(t.num.low.value) < 0 {
+		t.num.low = &n
+	}
+	if t.num.high == nil || n.value.Cmp(t.num.high.value) > 0 {
+		t.num.high = &n
+	}
+	if n.integer == nil {
+		t.Kind = join(t.Kind, Float)
+		return
+	}
+	t.Kind = join(t.Kind, Int)
+	if t.num.min == nil || n.integer.Cmp(t.num.min) < 0 {
+		t.num.min = n.integer
+	}
+	if t.num.max == nil || n.integer.Cmp(t.num.max) > 0 {
+		t.num.max = n.integer
+	}
+}
+
+// numberFormats sets the formats of the number types under t that need
+// more than an int64 or a float64: "uint64" for integers above the int64
+// range, "bigint" for integers beyond 64 bits and "decimal" for numbers a
+// float64 would round.
+func numberFormats(t *Type) {
+	walkTypes(t, func(t *Type) {
+		if t.num == nil || t.Format != "" {
+			return
+		}
+		switch t.Kind {
+		case Int:
+			switch {
+			case t.num.min.Cmp(minInt64) >= 0 && t.num.max.Cmp(maxInt64) <= 0:
+			case t.num.min.Sign() >= 0 && t.num.max.Cmp(maxUint64) <= 0:
+				t.Format = "uint64"
+			default:
+				t.Format = "bigint"
+			}
+		case Float:
+			if t.num.inexact != "" {
+				t.Format = "decimal"
+			}
+		}
+	})
+}
+
+// NarrowInts gives the integer types under t whose values all fit 32 bits
+// the format "int32".
+func NarrowInts(t *Type) {
+	walkTypes(t, func(t *Type) {
+		if t.Kind == Int && t.Format == "" && t.num != nil &&
+			t.num.min.Cmp(minInt32) >= 0 && t.num.max.Cmp(maxInt32) <= 0 {
+			t.Format = "int32"
+		}
+	})
+	nameTypes(t, t.Name)
+}
+
+// Precision reports the number types under t that a 64-bit integer or
+// float cannot hold, with the value that showed it.
+func Precision(t *Type) []Warning {
+	var warnings []Warning
+	walkTypes(t, func(t *Type) {
+		if t.num == nil || t.Override != nil && t.Override.Type != "" {
+			return
+		}
+		var msg string
+		switch t.Format {
+		case "uint64":
+			msg = t.num.max.String() + " does not fit a signed 64-bit integer, kept as unsigned"
+		case "bigint":
+			v := t.num.max
+			if v.Cmp(maxUint64) <= 0 {
+				v = t.num.min
+			}
+			msg = v.String() + " does not fit 64 bits, kept as a big integer"
+		case "decimal":
+			msg = t.num.inexact + " cannot be held exactly by a 64-bit float, kept as a decimal"
+		default:
+			return
+		}
+		warnings = append(warnings, Warning{t.Path, msg})
+	})
+	return warnings
+}
+
+// walkTypes calls fn for every type under t once, including the variants
+// of unions.
+func walkTypes(t *Type, fn func(*Type)) {
+	seen := make(map[*Type]bool)
+	var walk func(t *Type)
+	walk = func(t *Type) {
+		if t == nil || seen[t] {
+			return
+		}
+		seen[t] = true
+		fn(t)
+		for _, f := range t.Fields {
+			walk(f.Type)
+		}
+		for _, v := range t.Variants {
+			walk(v.Type)
+		}
+		walk(t.Elem)
+	}
+	walk(t)
+}
@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNumberTypes(t *testing.T) {
+	tests := []struct {
+		samples string
+		int32   bool
+		want    string
+	}{
+		{`{"v": 1}`, false, "int64"},
+		{`{"v": 1.0}`, false, "float64"},
+		{`{"v": 1e3}`, false, "float64"},
+		{`{"v": 1} {"v": 2.5}`, false, "float64"},
+		{`{"v": 9223372036854775807}`, false, "int64"},
+		{`{"v": -9223372036854775808}`, false, "int64"},
+		{`{"v": 18446744073709551615}`, false, "uint64"},
+		{`{"v": 18446744073709551616}`, false, "*big.Int"},
+		{`{"v": -9223372036854775809}`, false, "*big.Int"},
+		{`{"v": 1} {"v": 18446744073709551615} {"v": -1}`, false, "*big.Int"},
+		{`{"v": 0.1}`, false, "float64"},
+		{`{"v": 0.10000000000000000001}`, false, "json.Number"},
+		{`{"v": 2147483647} {"v": -2147483648}`, true, "int32"},
+		{`{"v": 2147483648}`, true, "int64"},
+	}
+	for _, tt := range tests {
+		root := Infer("Root", decode(t, tt.samples)...)
+		if tt.int32 {
+			NarrowInts(root)
+		}
+		opts := GoOptions{Tags: []string{"json"}}
+		out, err := Go(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fieldType(out, "V"); got != tt.want {
+			t.Errorf("%s: V is %s, want %s", tt.samples, got, tt.want)
+		}
+		if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+			t.Errorf("%s: diagnostics: %v", tt.samples, diags)
+		}
+		if w := Verify(root, opts, decode(t, tt.samples)...); len(w) > 0 {
+			t.Errorf("%s: %v", tt.samples, w)
+		}
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	root := Infer("Root", decode(t, `{"a": 18446744073709551615, "b": 18446744073709551616, "c": -18446744073709551616, "d": 0.10000000000000000001, "e": 1.5}`)...)
+	want := []Warning{
+		{"$.a", "18446744073709551615 does not fit a signed 64-bit integer, kept as unsigned"},
+		{"$.b", "18446744073709551616 does not fit 64 bits, kept as a big integer"},
+		{"$.c", "-18446744073709551616 does not fit 64 bits, kept as a big integer"},
+		{"$.d", "0.10000000000000000001 cannot be held exactly by a 64-bit float, kept as a decimal"},
+	}
+	if got := Precision(root); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Override adjusts the output generated for one location in the input.
+type Override struct {
+	// Type is the Go type of the value, such as "string" or "json.RawMessage".
+	Type string `json:"type,omitempty"`
+	// Import is the package Type refers to, needed unless it is one of
+	// encoding/json, math/big, net, net/netip, net/url or time.
+	Import string `json:"import,omitempty"`
+	// Name is the Go name of the field holding the value.
+	Name string `json:"name,omitempty"`
+	// TypeName is the name of the object type declared for the value.
+	TypeName string `json:"typeName,omitempty"`
+	// Tags are struct tags added to the field, replacing generated tags of
+	// the same key, or all of them with ReplaceTags.
+	Tags        map[string]string `json:"tags,omitempty"`
+	ReplaceTags bool              `json:"replaceTags,omitempty"`
+	// Omit leaves the field out of every output.
+	Omit bool `json:"omit,omitempty"`
+	// Raw stops recursion, keeping the value as undecoded JSON.
+	Raw bool `json:"raw,omitempty"`
+	// Extra gives the struct of an object a map keeping the keys it does not
+	// declare, re-encoded with the others. Go only.
+	Extra bool `json:"extra,omitempty"`
+}
+
+// Overrides map JSONPath-like paths, such as $.items[].id, to the overrides
+// for the values found there. Array indexes are written [] or [0].
+type Overrides map[string]*Override
+
+var goStdQualifiers = map[string]string{
+	"json":  "encoding/json",
+	"big":   "math/big",
+	"net":   "net",
+	"netip": "net/netip",
+	"url":   "net/url",
+	"time":  "time",
+}
+
+var indexPattern = regexp.MustCompile(`\[[0-9]+\]`)
+
+// normalPath replaces array indexes in path with [].
+func normalPath(path string) string {
+	return indexPattern.ReplaceAllString(path, "[]")
+}
+
+// ParseOverrides reads an overrides document, rejecting unknown options and
+// names or types Go could not declare. An empty document has no overrides.
+func ParseOverrides(s string) (Overrides, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var raw Overrides
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("overrides: %v", err)
+	}
+
+	o := make(Overrides, len(raw))
+	for path, ov := range raw {
+		if ov == nil {
+			continue
+		}
+		if !strings.HasPrefix(path, "$") {
+			return nil, fmt.Errorf("overrides: path %q does not start with $", path)
+		}
+		for _, name := range []string{ov.Name, ov.TypeName} {
+			if name != "" && !(token.IsIdentifier(name) && token.IsExported(name)) {
+				return nil, fmt.Errorf("overrides: %s: %q is not an exported Go identifier", path, name)
+			}
+		}
+		if ov.Type != "" {
+			expr, err := parser.ParseExpr(ov.Type)
+			if err != nil {
+				return nil, fmt.Errorf("overrides: %s: type %q: %v", path, ov.Type, err)
+			}
+			if ov.Import == "" {
+				if q := qualifier(expr); q != "" && goStdQualifiers[q] == "" {
+					return nil, fmt.Errorf("overrides: %s: type %q needs an import", path, ov.Type)
+				}
+			}
+		}
+		for key := range ov.Tags {
+			if !validTag(key + `:""`) {
+				return nil, fmt.Errorf("overrides: %s: %q is not a struct tag key", path, key)
+			}
+		}
+		o[normalPath(path)] = ov
+	}
+	return o, nil
+}
+
+// qualifier returns the package name a type expression refers to, if any.
+func qualifier(expr ast.Expr) string {
+	var q string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				q = id.Name
+			}
+			return false
+		}
+		return true
+	})
+	return q
+}
+
+// Apply attaches the overrides to the types they name in root, removes omitted
+// fields and names types again so values overridden differently do not share
+// a declaration. Overrides that match nothing are reported.
+func (o Overrides) Apply(root *Type) []Warning {
+	if len(o) == 0 {
+		return nil
+	}
+	used := make(map[string]bool)
+	seen := make(map[*Type]bool)
+	var walk func(t *Type, path string)
+	var fields func(t *Type, path string)
+	walk = func(t *Type, path string) {
+		if t == nil || seen[t] {
+			return
+		}
+		seen[t] = true
+		if ov := o[path]; ov != nil {
+			used[path] = true
+			t.Override = ov
+			if ov.Raw {
+				t.Kind, t.Format, t.Fields, t.Elem, t.index = Any, "json", nil, nil, nil
+				t.Discriminator, t.Variants, t.Quoted = "", nil, false
+			}
+		}
+		switch t.Kind {
+		case Object:
+			fields(t, path)
+			// the variants of a union share its path
+			for _, v := range t.Variants {
+				if !seen[v.Type] {
+					seen[v.Type] = true
+					fields(v.Type, path)
+				}
+			}
+		case Array:
+			if t.Elem == nil && o[path+"[]"] != nil {
+				// only empty arrays were seen, the override types the elements
+				t.Elem = &Type{Kind: Any, Path: path + "[]"}
+			}
+			walk(t.Elem, path+"[]")
+		case Map:
+			walk(t.Elem, path+"[*]")
+		}
+	}
+	fields = func(t *Type, path string) {
+		kept := t.Fields[:0]
+		for _, f := range t.Fields {
+			p := childPath(path, f.Key)
+			if ov := o[p]; ov != nil {
+				used[p] = true
+				if ov.Omit {
+					delete(t.index, f.Key)
+					if root.omitted == nil {
+						root.omitted = make(map[string]bool)
+					}
+					root.omitted[p] = true
+					continue
+				}
+				f.Override = ov
+			}
+			kept = append(kept, f)
+			walk(f.Type, p)
+		}
+		t.Fields = kept
+	}
+	walk(root, "$")
+
+	nameTypes(root, root.Name)
+	for t := range seen {
+		if t.Override != nil && t.Override.TypeName != "" && (t.Kind == Object || t == root) {
+			t.Name = t.Override.TypeName
+		}
+	}
+
+	var warnings []Warning
+	paths := make([]string, 0, len(o))
+	for path := range o {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if !used[path] {
+			warnings = append(warnings, Warning{path, "override matches nothing in the input"})
+		}
+	}
+	return warnings
+}
+
+// signature describes the overrides of a type or field for comparing shapes.
+func (ov *Override) signature() string {
+	if ov == nil {
+		return ""
+	}
+	b, _ := json.Marshal(ov)
+	return "!" + string(b)
+}
@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+const overrideDoc = `{
+	"items": [{"id": 1, "name": "a", "secret": "s"}],
+	"user": {"login": "x"},
+	"payload": {"deep": {"er": true}},
+	"created": "2020-01-02"
+}`
+
+func TestOverrides(t *testing.T) {
+	overrides, err := ParseOverrides(`{
+		"$.items[].id": {"type": "json.Number"},
+		"$.items[0].name": {"name": "Title", "tags": {"db": "title"}},
+		"$.items[].secret": {"omit": true},
+		"$.user": {"typeName": "Account"},
+		"$.payload": {"raw": true},
+		"$.created": {"type": "civil.Date", "import": "cloud.google.com/go/civil", "tags": {"json": "created_on"}, "replaceTags": true},
+		"$.missing": {"omit": true}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := Infer("Root", decode(t, overrideDoc)...)
+	var warnings []string
+	for _, w := range overrides.Apply(root) {
+		warnings = append(warnings, w.String())
+	}
+	if want := []string{"$.missing: override matches nothing in the input"}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings %q, want %q", warnings, want)
+	}
+	out, err := Go(root, GoOptions{Tags: []string{"json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "go_overrides", out)
+	golden(t, "python_overrides", Python(root, Dataclass))
+}
+
+func TestParseOverridesErrors(t *testing.T) {
+	for _, doc := range []string{
+		`{"items": {"omit": true}}`,
+		`{"$.a": {"nmae": "A"}}`,
+		`{"$.a": {"name": "a"}}`,
+		`{"$.a": {"typeName": "my type"}}`,
+		`{"$.a": {"type": "[]"}}`,
+		`{"$.a": {"type": "uuid.UUID"}}`,
+		`{"$.a": {"tags": {"bad key": "x"}}}`,
+		`[]`,
+	} {
+		if _, err := ParseOverrides(doc); err == nil {
+			t.Errorf("%s: no error", doc)
+		}
+	}
+}
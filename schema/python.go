@@ -0,0 +1,218 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PythonStyle selects the kind of class Python renders.
+type PythonStyle int
+
+const (
+	Dataclass PythonStyle = iota // standard library dataclasses
+	Pydantic                     // pydantic v2 models
+)
+
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true,
+	"assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true,
+	"if": true, "import": true, "in": true, "is": true, "lambda": true,
+	"nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// pyShadowed are attribute names that would hide a name used in annotations
+// or an attribute of the generated class' base.
+var pyShadowed = map[PythonStyle]map[string]bool{
+	Dataclass: {"bool": true, "int": true, "float": true, "str": true, "dataclasses": true},
+	Pydantic: {"bool": true, "int": true, "float": true, "str": true, "model_config": true,
+		"copy": true, "dict": true, "json": true, "schema": true, "schema_json": true,
+		"validate": true, "construct": true, "parse_obj": true, "parse_raw": true,
+		"parse_file": true, "from_orm": true, "update_forward_refs": true},
+}
+
+// pyImported are class names that would collide with the module's imports.
+var pyImported = map[string]bool{
+	"Any": true, "Dict": true, "List": true, "Optional": true,
+	"BaseModel": true, "ConfigDict": true, "Field": true,
+	"False": true, "None": true, "True": true,
+}
+
+type pyWriter struct {
+	style   PythonStyle
+	classes map[string]string // object type name -> class name
+	typing  map[string]bool   // names imported from typing
+	pydant  map[string]bool   // names imported from pydantic
+}
+
+// Python renders t as a Python module declaring one class per object type.
+// Classes are emitted after the classes they refer to so the module can be
+// imported without forward references.
+func Python(t *Type, style PythonStyle) []byte {
+	p := &pyWriter{
+		style:   style,
+		classes: make(map[string]string),
+		typing:  make(map[string]bool),
+		pydant:  make(map[string]bool),
+	}
+
+	objects := Objects(t)
+	for _, o := range objects {
+		name := o.Name
+		if pyImported[name] {
+			name += "Model"
+		}
+		p.classes[o.Name] = name
+	}
+
+	var body bytes.Buffer
+	for _, o := range objects {
+		body.WriteString("\n\n")
+		p.class(&body, o)
+	}
+	if t.Kind != Object {
+		fmt.Fprintf(&body, "\n\n%s = %s\n", t.Name, p.typeExpr(t))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("from __future__ import annotations\n\n")
+	if style == Dataclass {
+		out.WriteString("import dataclasses\n")
+	}
+	if len(p.typing) > 0 {
+		fmt.Fprintf(&out, "from typing import %s\n", strings.Join(sortedKeys(p.typing), ", "))
+	}
+	if style == Pydantic {
+		p.pydant["BaseModel"] = true
+		fmt.Fprintf(&out, "\nfrom pydantic import %s\n", strings.Join(sortedKeys(p.pydant), ", "))
+	}
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+type pyField struct {
+	name, key, typ string
+	optional       bool
+}
+
+func (p *pyWriter) class(w *bytes.Buffer, t *Type) {
+	var required, optional []pyField
+	used := make(map[string]bool)
+	aliased := false
+	for _, f := range t.Fields {
+		pf := pyField{name: p.fieldName(f.Key, used), key: f.Key, typ: p.typeExpr(f.Type)}
+		aliased = aliased || pf.name != pf.key
+		if t.Optional(f) {
+			pf.optional = true
+			if !strings.HasPrefix(pf.typ, "Optional[") && pf.typ != "Any" {
+				p.typing["Optional"] = true
+				pf.typ = "Optional[" + pf.typ + "]"
+			}
+			optional = append(optional, pf)
+		} else {
+			required = append(required, pf)
+		}
+	}
+
+	if p.style == Dataclass {
+		fmt.Fprintf(w, "@dataclasses.dataclass\nclass %s:\n", p.classes[t.Name])
+	} else {
+		fmt.Fprintf(w, "class %s(BaseModel):\n", p.classes[t.Name])
+		if aliased {
+			p.pydant["ConfigDict"] = true
+			w.WriteString("    model_config = ConfigDict(populate_by_name=True)\n\n")
+		}
+	}
+	if len(t.Fields) == 0 {
+		w.WriteString("    pass\n")
+	}
+	// fields with defaults must follow those without in a dataclass
+	for _, f := range append(required, optional...) {
+		fmt.Fprintf(w, "    %s: %s%s\n", f.name, f.typ, p.fieldDefault(f))
+	}
+}
+
+func (p *pyWriter) fieldDefault(f pyField) string {
+	var args []string
+	if f.optional {
+		args = append(args, "default=None")
+	}
+	if f.name != f.key {
+		if p.style == Dataclass {
+			args = append(args, fmt.Sprintf("metadata={\"json\": %s}", strconv.Quote(f.key)))
+		} else {
+			args = append(args, "alias="+strconv.Quote(f.key))
+		}
+	}
+
+	switch {
+	case len(args) == 0:
+		return ""
+	case f.optional && len(args) == 1:
+		return " = None"
+	case p.style == Dataclass:
+		return " = dataclasses.field(" + strings.Join(args, ", ") + ")"
+	}
+	p.pydant["Field"] = true
+	return " = Field(" + strings.Join(args, ", ") + ")"
+}
+
+// fieldName converts a JSON key into a snake_case attribute name that is
+// not yet in used.
+func (p *pyWriter) fieldName(key string, used map[string]bool) string {
+	name := snake(key)
+	if name == "" {
+		name = "field"
+	} else if unicode.IsDigit([]rune(name)[0]) {
+		name = "field_" + name
+	}
+	if pyKeywords[name] || pyShadowed[p.style][name] {
+		name += "_"
+	}
+	base := name
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	used[name] = true
+	return name
+}
+
+func (p *pyWriter) typeExpr(t *Type) string {
+	if t == nil {
+		p.typing["Any"] = true
+		return "Any"
+	}
+
+	var s string
+	switch t.Kind {
+	case Bool:
+		s = "bool"
+	case Int:
+		s = "int"
+	case Float:
+		s = "float"
+	case String:
+		s = "str"
+	case Object:
+		s = p.classes[t.Name]
+	case Array:
+		p.typing["List"] = true
+		s = "List[" + p.typeExpr(t.Elem) + "]"
+	case Map:
+		p.typing["Dict"] = true
+		s = "Dict[str, " + p.typeExpr(t.Elem) + "]"
+	default:
+		p.typing["Any"] = true
+		return "Any"
+	}
+	if t.Nullable {
+		p.typing["Optional"] = true
+		s = "Optional[" + s + "]"
+	}
+	return s
+}
@@ -0,0 +1,18 @@
+package schema
+
+import "testing"
+
+func TestPython(t *testing.T) {
+	tests := []struct {
+		name  string
+		style PythonStyle
+	}{
+		{"python_dataclass", Dataclass},
+		{"python_pydantic", Pydantic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden(t, tt.name, Python(fixture(t), tt.style))
+		})
+	}
+}
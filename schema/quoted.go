@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonNumber matches the strings holding a JSON number and nothing else.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// quoted records what the strings merged into a type hold.
+type quoted struct {
+	scalars  *Type  // the numbers and booleans the strings hold
+	first    string // the first string holding one
+	fraction bool   // a number was written with a fraction or an exponent
+	texts    int    // the strings holding neither
+	text     string // the first of them
+	// changed are the first numbers written back differently as an integer
+	// and as a float, such as "1.50" or "-0"
+	changed [2]string
+}
+
+// scalarOf returns the number or boolean s holds, as encoding/json writes
+// them for fields tagged with the ",string" option.
+func scalarOf(s string) (interface{}, bool) {
+	switch {
+	case s == "true", s == "false":
+		return s == "true", true
+	case jsonNumber.MatchString(s):
+		return json.Number(s), true
+	}
+	return nil, false
+}
+
+// quote records string s, merged into t.
+func (t *Type) quote(s string) {
+	if t.quoted == nil {
+		t.quoted = &quoted{}
+	}
+	q := t.quoted
+	v, ok := scalarOf(s)
+	if !ok {
+		if q.texts == 0 {
+			q.text = s
+		}
+		q.texts++
+		return
+	}
+	if q.scalars == nil {
+		q.first = s
+	}
+	if strings.ContainsAny(s, ".eE") {
+		q.fraction = true
+	}
+	for i, k := range [...]Kind{Int, Float} {
+		if _, ok := v.(json.Number); ok && q.changed[i] == "" && written(s, k) != s {
+			q.changed[i] = s
+		}
+	}
+	q.scalars = (&inferrer{}).add(q.scalars, v, t.Path)
+}
+
+// QuotedScalars turns the string fields under t whose values all hold
+// numbers, or all hold booleans, into fields of that kind read from and
+// written as strings. Fields whose strings disagree are kept as strings and
+// reported.
+func QuotedScalars(t *Type) []Warning {
+	var warnings []Warning
+	walkTypes(t, func(o *Type) {
+		// encoding/json quotes the values of fields only, not of elements
+		for _, f := range o.Fields {
+			if w := unquote(f.Type); w != "" {
+				warnings = append(warnings, Warning{f.Type.Path, w})
+			}
+		}
+	})
+	nameTypes(t, t.Name)
+	return warnings
+}
+
+// unquote turns string type t into a quoted number or boolean type if its
+// strings all hold one, or explains why not when some of them do.
+func unquote(t *Type) string {
+	if t == nil || t.Kind != String || t.quoted == nil || t.quoted.scalars == nil {
+		return ""
+	}
+	q := t.quoted
+	s := q.scalars
+	if s.Kind == Int && q.fraction {
+		s.Kind = Float
+	}
+	numberFormats(s)
+	var changed string
+	switch s.Kind {
+	case Int:
+		changed = q.changed[0]
+	case Float:
+		changed = q.changed[1]
+	}
+	switch {
+	case q.texts > 0:
+		return fmt.Sprintf("%q reads as %s but %q does not, kept as string", q.first, scalarName(s.Kind), q.text)
+	case s.Kind == Any:
+		return fmt.Sprintf("strings read as both numbers and booleans, such as %q, kept as string", q.first)
+	case s.Format == "bigint", s.Format == "decimal":
+		return fmt.Sprintf("%q reads as a number a 64-bit integer or float cannot hold, kept as string", q.first)
+	case changed != "":
+		return fmt.Sprintf("%q reads as a number but would be written back as %q, kept as string", changed, written(changed, s.Kind))
+	}
+	t.Kind, t.Format, t.num, t.Values, t.Quoted = s.Kind, s.Format, s.num, nil, true
+	return ""
+}
+
+// written returns number s as encoding/json writes it back from a field of
+// kind k, which is s itself only when s is spelled as strconv spells it.
+func written(s string, k Kind) string {
+	if k == Float {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return ""
+		}
+		b, err := json.Marshal(f)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return strconv.FormatInt(i, 10)
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return strconv.FormatUint(u, 10)
+	}
+	return ""
+}
+
+// quotes reports whether the values of t are written as strings, needing
+// the ",string" option in a json tag.
+func quotes(t *Type) bool {
+	return t != nil && t.Quoted && (t.Override == nil || t.Override.Type == "")
+}
+
+// scalarName describes a value of kind k in a sentence.
+func scalarName(k Kind) string {
+	if k == Bool {
+		return "a boolean"
+	}
+	return "a number"
+}
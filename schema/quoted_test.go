@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuotedScalars(t *testing.T) {
+	tests := []struct {
+		samples string
+		want    string // the Go field declaration
+		warning string // the start of the warning, if any
+	}{
+		{`{"v": "1"} {"v": "-20"}`, "int64 `json:\"v,string\"`", ""},
+		{`{"v": "1.5"} {"v": "2"}`, "float64 `json:\"v,string\"`", ""},
+		{`{"v": "true"} {"v": "false"}`, "bool `json:\"v,string\"`", ""},
+		{`{"v": "18446744073709551615"}`, "uint64 `json:\"v,string\"`", ""},
+		{`{"v": "1"} {"v": null}`, "*int64 `json:\"v,string\"`", ""},
+		{`{"v": "1"} {"v": "one"}`, "string `json:\"v\"`", `"1" reads as a number but "one" does not`},
+		{`{"v": "1"} {"v": "true"}`, "string `json:\"v\"`", "strings read as both numbers and booleans"},
+		{`{"v": "18446744073709551616"}`, "string `json:\"v\"`", `"18446744073709551616" reads as a number a 64-bit integer or float cannot hold`},
+		// numbers encoding/json would write back spelled differently
+		{`{"v": "1e5"}`, "string `json:\"v\"`", `"1e5" reads as a number but would be written back as "100000"`},
+		{`{"v": "-0"}`, "string `json:\"v\"`", `"-0" reads as a number but would be written back as "0"`},
+		{`{"v": "1.50"}`, "string `json:\"v\"`", `"1.50" reads as a number but would be written back as "1.5"`},
+		{`{"v": "01"}`, "string `json:\"v\"`", ""},
+		// elements cannot be quoted
+		{`{"v": ["1", "2"]}`, "[]string `json:\"v\"`", ""},
+	}
+	for _, tt := range tests {
+		samples := decode(t, tt.samples)
+		root := Infer("Root", samples...)
+		warnings := QuotedScalars(root)
+		switch {
+		case tt.warning == "" && len(warnings) > 0:
+			t.Errorf("%s: warnings %v", tt.samples, warnings)
+		case tt.warning != "" && (len(warnings) != 1 || !strings.HasPrefix(warnings[0].Message, tt.warning)):
+			t.Errorf("%s: warnings %v, want %q", tt.samples, warnings, tt.warning)
+		}
+
+		opts := GoOptions{Tags: []string{"json"}}
+		out, err := Go(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), "V "+tt.want) {
+			t.Errorf("%s: want V %s in\n%s", tt.samples, tt.want, out)
+		}
+		if w := Verify(root, opts, samples...); len(w) > 0 {
+			t.Errorf("%s: %v", tt.samples, w)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package schema
+
+import "strings"
+
+// inferrer merges values into types, sending the values found where the
+// samples nest an object inside one of the same shape to the type of the
+// enclosing object.
+type inferrer struct {
+	// recursive maps the path of each nested object to the path of the
+	// object it repeats.
+	recursive map[string]string
+	// types holds the type inferred at each path.
+	types map[string]*Type
+	// link returns the recursive types rather than merging values into them,
+	// for types built again from values already merged.
+	link bool
+}
+
+// target returns the type v, found at path, is merged into when path nests
+// an object inside one of the same shape.
+func (in *inferrer) target(path string, v interface{}) *Type {
+	p, ok := in.recursive[path]
+	if !ok {
+		return nil
+	}
+	r := in.types[p]
+	if r == nil {
+		return nil
+	}
+	switch v.(type) {
+	case nil:
+	case map[string]interface{}:
+		if !strings.HasSuffix(path, "[]") {
+			// a struct holding itself needs a pointer
+			r.Nullable = true
+		}
+	default:
+		return nil
+	}
+	return r
+}
+
+// recursion finds the objects t nests under a key of an object of much the
+// same shape, which have the key too, and maps their paths to the path of
+// the enclosing object. The key may hold the object or an array of them.
+func recursion(t *Type) map[string]string {
+	found := make(map[string]string)
+	var walk func(t *Type)
+	walk = func(t *Type) {
+		if t == nil {
+			return
+		}
+		switch t.Kind {
+		case Object:
+			for _, f := range t.Fields {
+				d := f.Type
+				if d != nil && d.Kind == Array {
+					d = d.Elem
+				}
+				if d != nil && d.Kind == Object && d.index[f.Key] != nil && sameShape(t, d) {
+					if _, ok := found[d.Path]; !ok {
+						found[d.Path] = t.Path
+					}
+				}
+				walk(f.Type)
+			}
+		case Array, Map:
+			walk(t.Elem)
+		}
+	}
+	walk(t)
+	return found
+}
+
+// sameShape reports whether objects a and b share most of their keys, with
+// values of the same kinds.
+func sameShape(a, b *Type) bool {
+	shared := 0
+	for _, f := range a.Fields {
+		g := b.index[f.Key]
+		if g == nil {
+			continue
+		}
+		if f.Type != nil && g.Type != nil && f.Type.Kind != g.Type.Kind && join(f.Type.Kind, g.Type.Kind) == Any {
+			return false
+		}
+		shared++
+	}
+	return shared*2 > len(a.Fields)+len(b.Fields)-shared
+}
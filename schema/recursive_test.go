@@ -0,0 +1,51 @@
+package schema
+
+import "testing"
+
+const treeDoc = `{"name": "root", "children": [
+	{"name": "a", "children": [{"name": "a1", "children": []}]},
+	{"name": "b", "children": []}
+]}`
+
+func TestRecursion(t *testing.T) {
+	tests := []struct {
+		samples string
+		field   string
+		want    string
+	}{
+		{treeDoc, "Children", "[]Node"},
+		{`{"name": "a", "next": {"name": "b", "next": {"name": "c", "next": null}}}`, "Next", "*Node"},
+		// the nested object does not have the key, so is not the same type
+		{`{"name": "a", "child": {"name": "b"}}`, "Child", "Child"},
+		// nor does an object of another shape
+		{`{"name": "a", "child": {"id": 1, "size": 2, "child": null}}`, "Child", "Child"},
+	}
+	for _, tt := range tests {
+		out, err := Go(Infer("Node", decode(t, tt.samples)...), GoOptions{Tags: []string{"json"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fieldType(out, tt.field); got != tt.want {
+			t.Errorf("%s: %s is %s, want %s\n%s", tt.samples, tt.field, got, tt.want, out)
+		}
+	}
+}
+
+func TestRecursionOutputs(t *testing.T) {
+	root := Infer("Node", decode(t, treeDoc)...)
+	opts := GoOptions{Tags: []string{"json"}}
+	out, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+		t.Errorf("diagnostics: %v", diags)
+	}
+	golden(t, "go_recursive", out)
+
+	ddl, _, err := SQL(root, SQLOptions{Dialect: Postgres})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "sql_recursive", ddl)
+}
@@ -0,0 +1,294 @@
+// Package schema infers a language neutral description of JSON documents,
+// which the emitters in this package render as source for other languages.
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the JSON type observed at a position in a document.
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Int
+	Float
+	String
+	Object
+	Array
+	Map // string keyed values of type Elem, only read from schemas
+	Any
+)
+
+var kindNames = []string{"null", "bool", "int", "float", "string", "object", "array", "map", "any"}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Type describes every value seen at one position across all samples.
+type Type struct {
+	Kind     Kind
+	Name     string   // type name for objects, assigned by Infer
+	Fields   []*Field // object fields, sorted by key
+	Elem     *Type    // array or map element type, nil if only empty arrays were seen
+	Nullable bool     // null was seen at this position
+	Format   string   // refinement of Kind, such as "date-time" strings or "int32" ints
+	Count    int      // number of values merged into this type
+	Path     string   // JSONPath-like location, such as $.items[].id
+	Override *Override
+	// Values counts each distinct string seen, until there are more than
+	// maxValues of them.
+	Values map[string]int
+	// Discriminator is the key whose value tells apart the Variants of an
+	// object type split by DetectUnions.
+	Discriminator string
+	Variants      []*Variant
+	// Seen lists the kinds of the non-null values merged into the type, in
+	// the order they were first seen; more than one for an Any type means
+	// values of conflicting kinds were widened.
+	Seen []Kind
+	// Quoted marks a number or boolean type whose values are written as
+	// strings, as encoding/json does for fields tagged with ",string".
+	Quoted bool
+
+	index   map[string]*Field
+	first   map[Kind]interface{} // first value of each kind in Seen
+	in      *inferrer            // how the root was inferred
+	num     *numbers             // the numbers merged into the type
+	quoted  *quoted              // what the strings merged into the type hold
+	runes   *span                // the lengths of the strings merged into the type
+	text    string               // the format textFormat gives all its strings, if shared
+	omitted map[string]bool      // paths of fields removed by overrides, on the root
+	tooMany bool                 // more than maxValues distinct strings were seen
+}
+
+// maxValues caps the distinct strings Infer counts at one position.
+const maxValues = 100
+
+// span is the range of some lengths, and how many there were.
+type span struct {
+	min, max, count int
+}
+
+// add widens s to hold n.
+func (s *span) add(n int) {
+	s.merge(span{n, n, 1})
+}
+
+// merge widens s to hold the lengths of o.
+func (s *span) merge(o span) {
+	if s.count == 0 || o.min < s.min {
+		s.min = o.min
+	}
+	if s.count == 0 || o.max > s.max {
+		s.max = o.max
+	}
+	s.count += o.count
+}
+
+// Field is an object member.
+type Field struct {
+	Key      string
+	Type     *Type
+	Count    int // number of objects the key was present in
+	Override *Override
+}
+
+// Optional reports whether f was missing from some of the objects merged into t.
+func (t *Type) Optional(f *Field) bool {
+	return f.Count < t.Count
+}
+
+// Infer merges samples into a single Type, naming the root object name.
+// Objects nested under a key of an object of much the same shape, such as
+// the children of a tree node, are merged into it, so the type refers to
+// itself rather than stopping at the depth of the samples.
+func Infer(name string, samples ...interface{}) *Type {
+	in := &inferrer{}
+	t := in.infer(samples)
+	if in.recursive = recursion(t); len(in.recursive) > 0 {
+		in.types = make(map[string]*Type)
+		t = in.infer(samples)
+	}
+	if t == nil {
+		t = &Type{Kind: Null, Path: "$"}
+	}
+	t.in = in
+	numberFormats(t)
+	nameTypes(t, name)
+	return t
+}
+
+func (in *inferrer) infer(samples []interface{}) *Type {
+	var t *Type
+	for _, s := range samples {
+		t = in.add(t, s, "$")
+	}
+	return t
+}
+
+// add merges value v found at path into t, allocating t if it is nil.
+func (in *inferrer) add(t *Type, v interface{}, path string) *Type {
+	if r := in.target(path, v); r != nil {
+		if in.link {
+			return r
+		}
+		t, path = r, r.Path
+	}
+	if t == nil {
+		t = &Type{Kind: Null, Path: path}
+		if in.types != nil && in.types[path] == nil {
+			in.types[path] = t
+		}
+	}
+	t.Count++
+	t.see(v)
+
+	switch v := v.(type) {
+	case nil:
+		t.Nullable = true
+	case bool:
+		t.Kind = join(t.Kind, Bool)
+	case float64, json.Number:
+		if n, ok := parseNumber(v); ok {
+			t.addNumber(n)
+		} else {
+			t.Kind = Any
+		}
+	case string:
+		if f := stringFormat(v); t.Kind == Null {
+			t.Format = f
+		} else if t.Format != f {
+			t.Format = ""
+		}
+		if f := textFormat(v); t.Kind == Null {
+			t.text = f
+		} else if t.text != f {
+			t.text = ""
+		}
+		t.Kind = join(t.Kind, String)
+		t.quote(v)
+		if t.runes == nil {
+			t.runes = &span{}
+		}
+		t.runes.add(utf8.RuneCountInString(v))
+		if !t.tooMany {
+			if t.Values == nil {
+				t.Values = make(map[string]int)
+			}
+			t.Values[v]++
+			if len(t.Values) > maxValues {
+				t.Values, t.tooMany = nil, true
+			}
+		}
+	case map[string]interface{}:
+		if t.Kind = join(t.Kind, Object); t.Kind != Object {
+			return t
+		}
+		if t.index == nil {
+			t.index = make(map[string]*Field)
+		}
+		for k, val := range v {
+			f, ok := t.index[k]
+			if !ok {
+				f = &Field{Key: k}
+				t.index[k] = f
+				t.Fields = append(t.Fields, f)
+			}
+			f.Count++
+			f.Type = in.add(f.Type, val, childPath(path, k))
+		}
+		sort.Slice(t.Fields, func(i, j int) bool { return t.Fields[i].Key < t.Fields[j].Key })
+	case []interface{}:
+		if t.Kind = join(t.Kind, Array); t.Kind != Array {
+			return t
+		}
+		for _, e := range v {
+			t.Elem = in.add(t.Elem, e, path+"[]")
+		}
+	default:
+		t.Kind = Any
+	}
+	return t
+}
+
+// see records the kind of v, and v as its example if it is the first.
+func (t *Type) see(v interface{}) {
+	k := kindOf(v)
+	if k == Null {
+		return
+	}
+	if _, ok := t.first[k]; ok {
+		return
+	}
+	if t.first == nil {
+		t.first = make(map[Kind]interface{})
+	}
+	t.first[k] = v
+	t.Seen = append(t.Seen, k)
+}
+
+// kindOf returns the kind of a decoded JSON value.
+func kindOf(v interface{}) Kind {
+	switch v := v.(type) {
+	case nil:
+		return Null
+	case bool:
+		return Bool
+	case float64, json.Number:
+		if n, ok := parseNumber(v); ok && n.integer != nil {
+			return Int
+		}
+		return Float
+	case string:
+		return String
+	case map[string]interface{}:
+		return Object
+	case []interface{}:
+		return Array
+	}
+	return Any
+}
+
+// join returns the kind able to hold values of both a and b.
+func join(a, b Kind) Kind {
+	switch {
+	case a == Null || a == b:
+		return b
+	case a == Int && b == Float, a == Float && b == Int:
+		return Float
+	}
+	return Any
+}
+
+// stringFormat names the format of s when it is a recognised timestamp.
+func stringFormat(s string) string {
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return "date"
+	}
+	return ""
+}
+
+// childPath appends key to a JSONPath-like path, bracketing keys that are
+// not plain identifiers.
+func childPath(path, key string) string {
+	for i, r := range key {
+		if !(r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return path + "[" + strconv.Quote(key) + "]"
+		}
+	}
+	if key == "" {
+		return path + `[""]`
+	}
+	return path + "." + key
+}
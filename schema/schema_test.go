@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// decode reads each JSON document in s as main does.
+func decode(t *testing.T, s string) []interface{} {
+	t.Helper()
+	var docs []interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	for dec.More() {
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			t.Fatalf("decoding %s: %v", s, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// fixture infers the types of the samples in testdata/fixture.jsonl.
+func fixture(t *testing.T) *Type {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fixture.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Infer("Customer", decode(t, string(data))...)
+}
+
+// golden compares got with testdata/name.golden, rewriting it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, rerun with -update if that is intended:\n%s", path, got)
+	}
+}
+
+// typeAt follows keys down from t, with "[]" for array elements.
+func typeAt(t *Type, keys ...string) *Type {
+	for _, k := range keys {
+		if t == nil {
+			return nil
+		}
+		if k == "[]" {
+			t = t.Elem
+			continue
+		}
+		f := t.index[k]
+		if f == nil {
+			return nil
+		}
+		t = f.Type
+	}
+	return t
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  string
+		key      string
+		kind     Kind
+		format   string
+		nullable bool
+	}{
+		{"int", `{"v": 1}`, "v", Int, "", false},
+		{"negative int", `{"v": -42}`, "v", Int, "", false},
+		{"float", `{"v": 1.5}`, "v", Float, "", false},
+		{"ints and floats", `{"v": 1} {"v": 2.5}`, "v", Float, "", false},
+		{"string", `{"v": "a"}`, "v", String, "", false},
+		{"date-time", `{"v": "2020-01-02T03:04:05Z"}`, "v", String, "date-time", false},
+		{"date", `{"v": "2020-01-02"}`, "v", String, "date", false},
+		{"dates and text", `{"v": "2020-01-02"} {"v": "soon"}`, "v", String, "", false},
+		{"bool", `{"v": true}`, "v", Bool, "", false},
+		{"object", `{"v": {}}`, "v", Object, "", false},
+		{"array", `{"v": []}`, "v", Array, "", false},
+		{"nullable", `{"v": "a"} {"v": null}`, "v", String, "", true},
+		{"only null", `{"v": null}`, "v", Null, "", true},
+		{"conflicting", `{"v": "a"} {"v": 1}`, "v", Any, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Infer("Root", decode(t, tt.samples)...)
+			v := typeAt(root, tt.key)
+			if v == nil {
+				t.Fatalf("no type at %s", tt.key)
+			}
+			if v.Kind != tt.kind || v.Format != tt.format || v.Nullable != tt.nullable {
+				t.Errorf("got %v %q nullable=%v, want %v %q nullable=%v", v.Kind, v.Format, v.Nullable, tt.kind, tt.format, tt.nullable)
+			}
+		})
+	}
+}
+
+func TestInferOptional(t *testing.T) {
+	root := Infer("Root", decode(t, `{"a": 1, "b": 2} {"a": 3}`)...)
+	for _, f := range root.Fields {
+		if want := f.Key == "b"; root.Optional(f) != want {
+			t.Errorf("%s: optional = %v, want %v", f.Key, root.Optional(f), want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one level of a selector: an object key, an array index or every
+// element of an array. JSON Pointer tokens are keys that index arrays too.
+type step struct {
+	key   string
+	index int
+	wild  bool
+	ptr   bool
+}
+
+// Select picks the values selector points at in doc, which is either a
+// JSONPath, such as $.data.results[*], or a JSON Pointer, such as
+// /data/results. A path may select every element of an array with [*], []
+// or .*, giving one value per element.
+//
+// With envelope set Select returns doc itself, pruned to the keys along the
+// path, so the types enclosing the selection are generated with it.
+func Select(doc interface{}, selector string, envelope bool) ([]interface{}, error) {
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	if envelope {
+		v, err := prune(doc, steps, "$")
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{v}, nil
+	}
+	return selectSteps(doc, steps, "$")
+}
+
+func selectSteps(v interface{}, steps []step, path string) ([]interface{}, error) {
+	if len(steps) == 0 {
+		return []interface{}{v}, nil
+	}
+	s := steps[0]
+	if s.wild {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("select: %s is %s, not an array", path, describe(v))
+		}
+		var list []interface{}
+		for i, e := range arr {
+			vs, err := selectSteps(e, steps[1:], fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, vs...)
+		}
+		return list, nil
+	}
+	child, path, err := s.apply(v, path)
+	if err != nil {
+		return nil, err
+	}
+	return selectSteps(child, steps[1:], path)
+}
+
+// prune keeps the parts of v along steps.
+func prune(v interface{}, steps []step, path string) (interface{}, error) {
+	if len(steps) == 0 {
+		return v, nil
+	}
+	s := steps[0]
+	if arr, ok := v.([]interface{}); ok && (s.wild || s.isIndex()) {
+		if !s.wild {
+			// the envelope keeps the one element
+			e, p, err := s.apply(v, path)
+			if err != nil {
+				return nil, err
+			}
+			e, err = prune(e, steps[1:], p)
+			return []interface{}{e}, err
+		}
+		out := make([]interface{}, len(arr))
+		for i, e := range arr {
+			var err error
+			if out[i], err = prune(e, steps[1:], fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	if s.wild {
+		return nil, fmt.Errorf("select: %s is %s, not an array", path, describe(v))
+	}
+	child, p, err := s.apply(v, path)
+	if err != nil {
+		return nil, err
+	}
+	child, err = prune(child, steps[1:], p)
+	return map[string]interface{}{s.key: child}, err
+}
+
+func (s step) isIndex() bool {
+	if s.ptr {
+		_, err := strconv.Atoi(s.key)
+		return err == nil
+	}
+	return s.index >= 0
+}
+
+// apply follows a key or index step from v, returning the value and its path.
+func (s step) apply(v interface{}, path string) (interface{}, string, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if !s.ptr && s.index >= 0 {
+			break
+		}
+		child, ok := v[s.key]
+		if !ok {
+			return nil, "", fmt.Errorf("select: %s has no key %q", path, s.key)
+		}
+		return child, childPath(path, s.key), nil
+	case []interface{}:
+		i := s.index
+		if s.ptr {
+			var err error
+			if i, err = strconv.Atoi(s.key); err != nil {
+				break
+			}
+		}
+		if i < 0 || i >= len(v) {
+			return nil, "", fmt.Errorf("select: %s has no element %d", path, i)
+		}
+		return v[i], fmt.Sprintf("%s[%d]", path, i), nil
+	}
+	if s.ptr || s.index < 0 {
+		return nil, "", fmt.Errorf("select: %s is %s, not an object", path, describe(v))
+	}
+	return nil, "", fmt.Errorf("select: %s is %s, not an array", path, describe(v))
+}
+
+func parseSelector(sel string) ([]step, error) {
+	switch {
+	case sel == "" || sel == "$":
+		return nil, nil
+	case strings.HasPrefix(sel, "/"):
+		var steps []step
+		for _, tok := range strings.Split(sel[1:], "/") {
+			tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+			steps = append(steps, step{key: tok, ptr: true})
+		}
+		return steps, nil
+	case !strings.HasPrefix(sel, "$"):
+		return nil, fmt.Errorf("select: %q is neither a JSONPath starting with $ nor a JSON Pointer starting with /", sel)
+	}
+
+	var steps []step
+	s := sel[1:]
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, "[*]"):
+			steps = append(steps, step{index: -1, wild: true})
+			s = s[3:]
+		case strings.HasPrefix(s, "[]"), strings.HasPrefix(s, ".*"):
+			steps = append(steps, step{index: -1, wild: true})
+			s = s[2:]
+		case s[0] == '.':
+			end := strings.IndexAny(s[1:], ".[")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("select: empty key in %q", sel)
+			}
+			steps = append(steps, step{key: s[1 : end+1], index: -1})
+			s = s[end+1:]
+		case strings.HasPrefix(s, `["`), strings.HasPrefix(s, "['"):
+			key, rest, err := quotedKey(s[1:])
+			if err != nil || !strings.HasPrefix(rest, "]") {
+				return nil, fmt.Errorf("select: bad key at %q in %q", s, sel)
+			}
+			steps = append(steps, step{key: key, index: -1})
+			s = rest[1:]
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("select: unterminated index at %q in %q", s, sel)
+			}
+			i, err := strconv.Atoi(s[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("select: bad index at %q in %q", s, sel)
+			}
+			steps = append(steps, step{index: i})
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("select: unexpected %q in %q", s, sel)
+		}
+	}
+	return steps, nil
+}
+
+// quotedKey reads a single or double quoted key from the start of s.
+func quotedKey(s string) (key, rest string, err error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			lit := s[:i+1]
+			if q == '\'' {
+				lit = `"` + strings.ReplaceAll(strings.ReplaceAll(lit[1:i], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			key, err = strconv.Unquote(lit)
+			return key, s[i+1:], err
+		}
+	}
+	return "", "", fmt.Errorf("unterminated key")
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const selectDoc = `{
+	"data": {"results": [{"id": 1}, {"id": 2}], "next": null},
+	"odd key": {"a/b": "x", "m~n": "y"},
+	"meta": {"a": 1, "b": 2}
+}`
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		selector string
+		envelope bool
+		want     string // the selected values as a JSON array
+	}{
+		{"$", false, `[` + `{"data":{"next":null,"results":[{"id":1},{"id":2}]},"meta":{"a":1,"b":2},"odd key":{"a/b":"x","m~n":"y"}}` + `]`},
+		{"$.data.results", false, `[[{"id":1},{"id":2}]]`},
+		{"$.data.results[*]", false, `[{"id":1},{"id":2}]`},
+		{"$.data.results[]", false, `[{"id":1},{"id":2}]`},
+		{"$.data.results[1]", false, `[{"id":2}]`},
+		{"$.data.results[*].id", false, `[1,2]`},
+		{"$.data.results.*", false, `[{"id":1},{"id":2}]`},
+		{`$["odd key"]["a/b"]`, false, `["x"]`},
+		{"/data/results/0", false, `[{"id":1}]`},
+		{"/odd key/a~1b", false, `["x"]`},
+		{"/odd key/m~0n", false, `["y"]`},
+		{"$.data.results[0]", true, `[{"data":{"results":[{"id":1}]}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := Select(decode(t, selectDoc)[0], tt.selector, tt.envelope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	for _, selector := range []string{"data.results", "$.data[", `$["a]`, "$.data[x]", "$.missing", "$.meta.*", "$.data.results[5]"} {
+		if _, err := Select(decode(t, selectDoc)[0], selector, false); err == nil {
+			t.Errorf("%q: no error", selector)
+		}
+	}
+}
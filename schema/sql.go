@@ -0,0 +1,318 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+// SQLDialect selects the column types and key syntax SQL renders.
+type SQLDialect int
+
+const (
+	Postgres SQLDialect = iota
+	SQLite
+)
+
+// SQLOptions controls how SQL maps a document onto tables.
+type SQLOptions struct {
+	Dialect SQLDialect
+	// Flatten stores nested objects as prefixed columns of the enclosing
+	// table rather than as a single JSON column.
+	Flatten bool
+	Naming  Naming // of the Go row structs
+}
+
+// sqlReserved holds the keywords each dialect refuses as bare identifiers.
+var sqlReserved = map[SQLDialect]map[string]bool{
+	Postgres: wordSet(`all analyse analyze and any array as asc asymmetric
+		authorization binary both case cast check collate collation column
+		concurrently constraint create cross current_catalog current_date
+		current_role current_schema current_time current_timestamp current_user
+		default deferrable desc distinct do else end except false fetch for
+		foreign freeze from full grant group having ilike in initially inner
+		intersect into is isnull join lateral leading left like limit localtime
+		localtimestamp natural not notnull null offset on only or order outer
+		overlaps placing primary references returning right select session_user
+		similar some symmetric table tablesample then to trailing true union
+		unique user using variadic verbose when where window with`),
+	SQLite: wordSet(`abort action add after all alter always analyze and as asc
+		attach autoincrement before begin between by cascade case cast check
+		collate column commit conflict constraint create cross current
+		current_date current_time current_timestamp database default deferrable
+		deferred delete desc detach distinct do drop each else end escape except
+		exclude exclusive exists explain fail filter first following for foreign
+		from full generated glob group groups having if ignore immediate in index
+		indexed initially inner insert instead intersect into is isnull join key
+		last left like limit match materialized natural no not nothing notnull
+		null nulls of offset on or order others outer over partition plan pragma
+		preceding primary query raise range recursive references regexp reindex
+		release rename replace restrict returning right rollback row rows
+		savepoint select set table temp temporary then ties to transaction
+		trigger unbounded union unique update using vacuum values view virtual
+		when where window with without`),
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+type table struct {
+	name    string
+	goName  string
+	columns []*column
+	used    map[string]bool
+}
+
+type column struct {
+	name    string
+	kind    Kind   // Object and Array columns hold JSON
+	format  string // format of String columns, such as "uuid"
+	notNull bool
+	primary bool
+	serial  bool   // synthetic, database assigned key
+	refs    *table // foreign key to the parent row
+}
+
+type sqlBuilder struct {
+	opts   SQLOptions
+	tables []*table
+	// used holds the Go names given to row structs.
+	used map[string]bool
+	// open holds the tables of the objects enclosing the one being added,
+	// and nil for objects being flattened.
+	open map[*Type]*table
+}
+
+// SQL renders CREATE TABLE statements for the root object of t, or for the
+// elements of a root array of objects, along with Go structs carrying db tags
+// for each table. Arrays of objects become child tables with a foreign key to
+// the enclosing row; other arrays are stored as JSON.
+func SQL(t *Type, opts SQLOptions) (ddl, src []byte, err error) {
+	name := t.Name
+	if t.Kind == Array && t.Elem != nil {
+		t = t.Elem
+	}
+	if t.Kind != Object {
+		return nil, nil, fmt.Errorf("SQL output needs an object or an array of objects, got %s", t.Kind)
+	}
+
+	b := &sqlBuilder{opts: opts, used: make(map[string]bool), open: make(map[*Type]*table)}
+	b.table(t, snake(name), nil)
+	src, err = b.goSource()
+	return b.ddl(), src, err
+}
+
+// table adds a table for the objects described by t, keyed by their "id"
+// field when every object has a usable one.
+func (b *sqlBuilder) table(t *Type, name string, parent *table) *table {
+	tb := &table{name: name, goName: b.goName(t), used: make(map[string]bool)}
+	b.tables = append(b.tables, tb)
+	b.open[t] = tb
+	defer delete(b.open, t)
+
+	var pk *column
+	for _, f := range t.Fields {
+		if f.Key == "id" && !t.Optional(f) && !f.Type.Nullable && (f.Type.Kind == Int || f.Type.Kind == String) {
+			pk = tb.add("id", f.Type.Kind, true)
+			pk.primary, pk.format = true, f.Type.Format
+		}
+	}
+	if pk == nil {
+		pk = tb.add("id", Int, true)
+		pk.primary, pk.serial = true, true
+	}
+	if parent != nil {
+		parentKey := parent.columns[0]
+		fk := tb.add(parent.name+"_"+parentKey.name, parentKey.kind, true)
+		fk.refs, fk.format = parent, parentKey.format
+	}
+
+	b.columns(tb, t, "", true)
+	return tb
+}
+
+// goName names the row struct of the objects described by t as the Go
+// output names their struct, numbering names another table took.
+func (b *sqlBuilder) goName(t *Type) string {
+	base := b.opts.Naming.typeName(t.Name)
+	name := base
+	for i := 2; b.used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	b.used[name] = true
+	return name
+}
+
+// columns adds a column to tb for each field of t, prefixing names with
+// prefix. notNull is false when the enclosing object may be absent.
+func (b *sqlBuilder) columns(tb *table, t *Type, prefix string, notNull bool) {
+	for _, f := range t.Fields {
+		if prefix == "" && f.Key == "id" && tb.columns[0].name == "id" && !tb.columns[0].serial {
+			continue
+		}
+		ft := f.Type
+		required := notNull && !t.Optional(f) && !ft.Nullable
+		name := prefix + snake(f.Key)
+
+		_, recursive := b.open[ft]
+		switch {
+		case ft.Kind == Object && b.opts.Flatten && !recursive:
+			b.open[ft] = nil
+			b.columns(tb, ft, name+"_", required)
+			delete(b.open, ft)
+		case ft.Kind == Array && ft.Elem != nil && ft.Elem.Kind == Object:
+			if parent, ok := b.open[ft.Elem]; ok && parent != nil {
+				// rows of a recursive type refer to their parent row
+				fk := parent.add("parent_"+parent.columns[0].name, parent.columns[0].kind, false)
+				fk.refs, fk.format = parent, parent.columns[0].format
+				break
+			}
+			b.table(ft.Elem, tb.name+"_"+snake(f.Key), tb)
+		default:
+			tb.add(name, ft.Kind, required).format = ft.Format
+		}
+	}
+}
+
+func (tb *table) add(name string, kind Kind, notNull bool) *column {
+	if name == "" {
+		name = "column"
+	}
+	base := name
+	for i := 2; tb.used[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	tb.used[name] = true
+	c := &column{name: name, kind: kind, notNull: notNull}
+	tb.columns = append(tb.columns, c)
+	return c
+}
+
+func (b *sqlBuilder) sqlType(c *column) string {
+	if b.opts.Dialect == SQLite {
+		switch {
+		case c.serial, c.kind == Bool, c.kind == Int:
+			return "INTEGER"
+		case c.kind == Float:
+			return "REAL"
+		}
+		return "TEXT"
+	}
+
+	switch c.kind {
+	case Bool:
+		return "BOOLEAN"
+	case Int:
+		if c.serial {
+			return "BIGSERIAL"
+		}
+		return "BIGINT"
+	case Float:
+		return "DOUBLE PRECISION"
+	case String:
+		switch c.format {
+		case "uuid":
+			return "UUID"
+		case "ipv4", "ipv6":
+			return "INET"
+		}
+		return "TEXT"
+	}
+	return "JSONB"
+}
+
+func (b *sqlBuilder) ddl() []byte {
+	var buf bytes.Buffer
+	for i, tb := range b.tables {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "CREATE TABLE %s (\n", b.ident(tb.name))
+		for j, c := range tb.columns {
+			def := b.ident(c.name) + " " + b.sqlType(c)
+			switch {
+			case c.primary:
+				def += " PRIMARY KEY"
+			case c.notNull:
+				def += " NOT NULL"
+			}
+			if c.refs != nil {
+				def += fmt.Sprintf(" REFERENCES %s (%s)", b.ident(c.refs.name), b.ident(c.refs.columns[0].name))
+			}
+			if j < len(tb.columns)-1 {
+				def += ","
+			}
+			fmt.Fprintf(&buf, "    %s\n", def)
+		}
+		buf.WriteString(");\n")
+	}
+	return buf.Bytes()
+}
+
+func (b *sqlBuilder) goSource() ([]byte, error) {
+	var body bytes.Buffer
+	needJSON := false
+	for _, tb := range b.tables {
+		fmt.Fprintf(&body, "\n// %s is a row of the %s table.\ntype %s struct {\n", tb.goName, tb.name, tb.goName)
+		used := make(map[string]bool)
+		for _, c := range tb.columns {
+			name := b.opts.Naming.name(c.name)
+			base := name
+			for i := 2; used[name]; i++ {
+				name = fmt.Sprintf("%s%d", base, i)
+			}
+			used[name] = true
+
+			var typ string
+			switch c.kind {
+			case Bool:
+				typ = "bool"
+			case Int:
+				typ = "int64"
+			case Float:
+				typ = "float64"
+			case String:
+				typ = "string"
+			default:
+				typ = "json.RawMessage"
+				needJSON = true
+			}
+			if !c.notNull && typ != "json.RawMessage" {
+				typ = "*" + typ
+			}
+			fmt.Fprintf(&body, "%s %s `db:\"%s\"`\n", name, typ, c.name)
+		}
+		body.WriteString("}\n")
+	}
+
+	src := "package main\n"
+	if needJSON {
+		src += "\nimport \"encoding/json\"\n"
+	}
+	src += body.String()
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		err = fmt.Errorf("error formatting: %s, was formatting\n%s", err, src)
+	}
+	return formatted, err
+}
+
+// ident quotes name when it is a reserved word or starts with a digit.
+func (b *sqlBuilder) ident(name string) string {
+	if sqlReserved[b.opts.Dialect][name] || name[0] >= '0' && name[0] <= '9' {
+		return `"` + name + `"`
+	}
+	return name
+}
+
+// snake converts an identifier or JSON key to lower snake_case.
+func snake(s string) string {
+	return strings.Join(words(s), "_")
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SQLOptions
+	}{
+		{"sql_postgres", SQLOptions{Dialect: Postgres}},
+		{"sql_sqlite", SQLOptions{Dialect: SQLite}},
+		{"sql_flatten", SQLOptions{Dialect: Postgres, Flatten: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddl, src, err := SQL(fixture(t), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tt.name, append(append(ddl, "\n"...), src...))
+		})
+	}
+}
+
+func TestSQLRowNames(t *testing.T) {
+	// row structs are named after their types, as the Go output names them
+	root := Infer("MyJsonName", decode(t, `{"id": 1, "line_items": [{"sku": "a"}]}`)...)
+	_, src, err := SQL(root, SQLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range regexp.MustCompile(`type (\w+) struct`).FindAllSubmatch(src, -1) {
+		names = append(names, string(m[1]))
+	}
+	if want := []string{"MyJsonName", "LineItem"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("row structs %v, want %v", names, want)
+	}
+}
+
+func TestSQLNotObject(t *testing.T) {
+	if _, _, err := SQL(Infer("Root", decode(t, `[1, 2]`)...), SQLOptions{}); err == nil {
+		t.Error("no error for an array of numbers")
+	}
+}
@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Variant is the type of the objects of a union whose discriminator key
+// holds Value.
+type Variant struct {
+	Value string
+	Type  *Type
+}
+
+// discriminators are the keys DetectUnions tries, in order.
+var discriminators = []string{"type", "kind", "event", "eventType", "event_type", "@type", "_type", "object", "op", "action"}
+
+// maxVariants caps the distinct discriminator values of a union.
+const maxVariants = 20
+
+// DetectUnions looks for the elements of arrays of objects, and roots
+// merged from several samples, that mix shapes told apart by a string key,
+// such as "type" or "kind", and splits their object types into one variant
+// per value of the key. t must have been inferred from samples. When key is
+// empty the well known discriminator names are tried and only used when
+// the variants differ in shape; otherwise key is used wherever it is present
+// in every object. Emitters other than Go keep the merged types.
+func DetectUnions(t *Type, key string, samples ...interface{}) []Warning {
+	objects := make(map[*Type][]map[string]interface{})
+	var candidates []*Type
+	// elem marks the elements of arrays that are not themselves elements
+	var walk func(v interface{}, t *Type, elem, nested bool)
+	walk = func(v interface{}, t *Type, elem, nested bool) {
+		if t == nil {
+			return
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if t.Kind != Object {
+				return
+			}
+			if elem {
+				if _, ok := objects[t]; !ok {
+					candidates = append(candidates, t)
+				}
+				objects[t] = append(objects[t], v)
+			}
+			for k, val := range v {
+				if f := t.index[k]; f != nil {
+					walk(val, f.Type, false, false)
+				}
+			}
+		case []interface{}:
+			if t.Kind != Array {
+				return
+			}
+			for _, e := range v {
+				walk(e, t.Elem, !nested, true)
+			}
+		}
+	}
+	for _, s := range samples {
+		walk(s, t, len(samples) > 1, false)
+	}
+
+	// variants refer to recursive types rather than repeating them
+	in := &inferrer{link: true}
+	if t.in != nil {
+		in.recursive, in.types = t.in.recursive, t.in.types
+	}
+	var warnings []Warning
+	split := false
+	for _, c := range candidates {
+		if c == t && len(samples) == 1 {
+			// a recursive root is reached as an element too, but has to be
+			// decoded as a struct
+			continue
+		}
+		objs := objects[c]
+		disc := ""
+		if key != "" {
+			if discriminates(objs, key, false) {
+				disc = key
+			}
+		} else {
+			for _, k := range discriminators {
+				if discriminates(objs, k, true) {
+					disc = k
+					break
+				}
+			}
+		}
+		if disc == "" {
+			continue
+		}
+
+		byValue := make(map[string]*Type)
+		for _, o := range objs {
+			v := o[disc].(string)
+			byValue[v] = in.add(byValue[v], o, c.Path)
+		}
+		values := make([]string, 0, len(byValue))
+		for v := range byValue {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		c.Discriminator = disc
+		c.Variants = nil
+		for _, v := range values {
+			numberFormats(byValue[v])
+			c.Variants = append(c.Variants, &Variant{v, byValue[v]})
+		}
+		split = true
+		warnings = append(warnings, Warning{c.Path, fmt.Sprintf("split into %d variants by %q: %s",
+			len(values), disc, strings.Join(values, ", "))})
+	}
+	if split {
+		nameTypes(t, t.Name)
+	}
+	return warnings
+}
+
+// discriminates reports whether key, which must be nameable in a json struct
+// tag, holds a string in every object, taking between 2 and maxVariants
+// values. With shapes set the objects of some two values must also differ in
+// their keys.
+func discriminates(objs []map[string]interface{}, key string, shapes bool) bool {
+	if !validJSONName(key) {
+		return false
+	}
+	keys := make(map[string]map[string]bool)
+	for _, o := range objs {
+		v, ok := o[key].(string)
+		if !ok {
+			return false
+		}
+		if keys[v] == nil {
+			keys[v] = make(map[string]bool)
+		}
+		for k := range o {
+			keys[v][k] = true
+		}
+	}
+	if len(keys) < 2 || len(keys) > maxVariants {
+		return false
+	}
+	if !shapes {
+		return true
+	}
+	var first string
+	for _, set := range keys {
+		s := strings.Join(sortedKeys(set), "\x00")
+		if first == "" {
+			first = s
+		} else if s != first {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const unionDoc = `{"events": [
+	{"type": "click", "x": 1, "y": 2},
+	{"type": "view", "page": "/a"},
+	{"type": "click", "x": 3, "y": 4}
+]}`
+
+func TestDetectUnions(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples string
+		key     string
+		want    []Warning
+	}{
+		{"guessed", unionDoc, "", []Warning{{"$.events[]", `split into 2 variants by "type": click, view`}}},
+		{"same shape", `{"events": [{"type": "a", "v": 1}, {"type": "b", "v": 2}]}`, "", nil},
+		{"named key", `{"events": [{"type": "a", "v": 1}, {"type": "b", "v": 2}]}`, "type",
+			[]Warning{{"$.events[]", `split into 2 variants by "type": a, b`}}},
+		{"key missing", `{"events": [{"type": "a", "v": 1}, {"w": 2}]}`, "type", nil},
+		{"one value", `{"events": [{"type": "a", "v": 1}, {"type": "a", "w": 2}]}`, "", nil},
+		{"roots", `{"kind": "user", "name": "a"} {"kind": "team", "size": 2}`, "",
+			[]Warning{{"$", `split into 2 variants by "kind": team, user`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := decode(t, tt.samples)
+			got := DetectUnions(Infer("Root", samples...), tt.key, samples...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoUnions(t *testing.T) {
+	for _, tt := range []struct{ name, samples string }{
+		{"go_unions", unionDoc},
+		{"go_unions_root", `{"kind": "user", "name": "a"} {"kind": "team", "size": 2}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := decode(t, tt.samples)
+			root := Infer("Root", samples...)
+			DetectUnions(root, "", samples...)
+			opts := GoOptions{Tags: []string{"json"}}
+			out, err := Go(root, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+				t.Errorf("diagnostics: %v", diags)
+			}
+			golden(t, tt.name, out)
+		})
+	}
+}
+
+func TestGoUnionExample(t *testing.T) {
+	// a root named Example gives way to the variable, conversion included
+	samples := decode(t, `{"kind": "user", "name": "a"} {"kind": "team", "size": 2}`)
+	root := Infer("Example", samples...)
+	DetectUnions(root, "", samples...)
+	opts := GoOptions{Tags: []string{"json"}, Example: samples[0]}
+	out, err := Go(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := CheckGo(root, opts, "types.go", out, nil); len(diags) > 0 {
+		t.Errorf("diagnostics: %v\n%s", diags, out)
+	}
+	if !strings.Contains(string(out), "var Example = Example2(UserExample{") {
+		t.Errorf("no Example2 conversion in\n%s", out)
+	}
+}
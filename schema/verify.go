@@ -0,0 +1,332 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"math/big"
+	"net"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxWarnings caps the differences Verify reports.
+const maxWarnings = 50
+
+// Warning is a problem with generated output, located by a JSONPath-like
+// path into the input.
+type Warning struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return w.Path + ": " + w.Message
+}
+
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// overrideTypes are the Go types an override can name that Verify builds;
+// values of any other type are kept as raw JSON.
+var overrideTypes = map[string]reflect.Type{
+	"bool": reflect.TypeOf(false), "string": reflect.TypeOf(""),
+	"int": reflect.TypeOf(0), "int8": reflect.TypeOf(int8(0)), "int16": reflect.TypeOf(int16(0)),
+	"int32": reflect.TypeOf(int32(0)), "int64": reflect.TypeOf(int64(0)),
+	"uint": reflect.TypeOf(uint(0)), "uint8": reflect.TypeOf(uint8(0)), "uint16": reflect.TypeOf(uint16(0)),
+	"uint32": reflect.TypeOf(uint32(0)), "uint64": reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)), "float64": reflect.TypeOf(float64(0)),
+	"interface{}": interfaceType, "any": interfaceType,
+	"json.RawMessage": rawType, "time.Time": timeType,
+	"json.Number": reflect.TypeOf(json.Number("")), "big.Int": reflect.TypeOf(big.Int{}),
+	"net.IP": reflect.TypeOf(net.IP(nil)), "netip.Addr": reflect.TypeOf(netip.Addr{}),
+}
+
+// overrideType builds the Go type an override names, as far as it knows.
+func overrideType(typ string) reflect.Type {
+	switch {
+	case strings.HasPrefix(typ, "*"):
+		return reflect.PtrTo(overrideType(typ[1:]))
+	case strings.HasPrefix(typ, "[]"):
+		return reflect.SliceOf(overrideType(typ[2:]))
+	case strings.HasPrefix(typ, "map[string]"):
+		return reflect.MapOf(reflect.TypeOf(""), overrideType(typ[len("map[string]"):]))
+	}
+	if rt, ok := overrideTypes[typ]; ok {
+		return rt
+	}
+	return rawType
+}
+
+// Verify builds the Go type generated for t at runtime, decodes every
+// sample into it and encodes it again, reporting values that were lost or
+// altered on the way. Keys only present in the re-encoded document, such as
+// zero values for fields a sample omitted, are not reported.
+func Verify(t *Type, opts GoOptions, samples ...interface{}) (warnings []Warning) {
+	opts.Tags = []string{"json"}
+	g := newGoWriter(t, opts)
+
+	defer func() {
+		if r := recover(); r != nil {
+			warnings = append(warnings, Warning{"$", fmt.Sprintf("could not build the generated type: %v", r)})
+		}
+	}()
+	typ := g.reflectType(t, make(map[string]reflect.Type))
+
+	d := &differ{omitted: t.omitted}
+	for i, s := range samples {
+		path := "$"
+		if len(samples) > 1 {
+			path = fmt.Sprintf("$<sample %d>", i+1)
+		}
+		in, err := json.Marshal(s)
+		if err != nil {
+			d.add(path, err.Error())
+			continue
+		}
+		v := reflect.New(typ)
+		if err := json.Unmarshal(in, v.Interface()); err != nil {
+			d.add(path, "does not decode: "+err.Error())
+			// a type mismatch leaves the rest of the document decoded
+			if _, ok := err.(*json.UnmarshalTypeError); !ok {
+				continue
+			}
+		}
+		out, err := json.Marshal(v.Interface())
+		if err != nil {
+			d.add(path, "does not encode: "+err.Error())
+			continue
+		}
+		var back interface{}
+		dec := json.NewDecoder(bytes.NewReader(out))
+		dec.UseNumber()
+		if err := dec.Decode(&back); err != nil {
+			d.add(path, err.Error())
+			continue
+		}
+		d.diff(path, s, back)
+	}
+	if d.dropped > 0 {
+		d.warnings = append(d.warnings, Warning{"$", fmt.Sprintf("%d more differences not shown", d.dropped)})
+	}
+	return d.warnings
+}
+
+// reflectType mirrors typeExpr, building the type rather than naming it.
+func (g *goWriter) reflectType(t *Type, structs map[string]reflect.Type) reflect.Type {
+	if t == nil {
+		return interfaceType
+	}
+	if t.Override != nil && t.Override.Type != "" {
+		return overrideType(strings.ReplaceAll(t.Override.Type, " ", ""))
+	}
+
+	var rt reflect.Type
+	switch t.Kind {
+	case Bool:
+		rt = reflect.TypeOf(false)
+	case Int:
+		switch t.Format {
+		case "int32":
+			rt = reflect.TypeOf(int32(0))
+		case "uint64":
+			rt = reflect.TypeOf(uint64(0))
+		case "bigint":
+			return reflect.TypeOf((*big.Int)(nil))
+		default:
+			rt = reflect.TypeOf(int64(0))
+		}
+	case Float:
+		switch t.Format {
+		case "float":
+			rt = reflect.TypeOf(float32(0))
+		case "decimal":
+			rt = reflect.TypeOf(json.Number(""))
+		default:
+			rt = reflect.TypeOf(float64(0))
+		}
+	case String:
+		if ov := g.opts.FormatTypes[t.Format]; ov != nil && t.Format != "" {
+			typ := strings.ReplaceAll(ov.Type, " ", "")
+			if rt = overrideType(typ); strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "*") {
+				return rt
+			}
+			break
+		}
+		switch t.Format {
+		case "date-time":
+			rt = timeType
+		case "byte":
+			return reflect.TypeOf([]byte(nil))
+		default:
+			rt = reflect.TypeOf("")
+		}
+	case Object:
+		if isUnion(t) {
+			// the variants are decoded by generated code reflection cannot
+			// declare, so the values are kept as they are
+			return rawType
+		}
+		var ok bool
+		if rt, ok = structs[t.Name]; ok && rt == nil {
+			// reflect cannot build a struct holding itself, so nested
+			// values are kept as they are
+			return rawType
+		}
+		if !ok {
+			structs[t.Name] = nil
+			var fields []reflect.StructField
+			base := g.bases[t.Name]
+			if base != nil {
+				fields = append(fields, reflect.StructField{
+					Name:      base.name,
+					Type:      reflect.StructOf(g.reflectFields(base.fields, structs)),
+					Anonymous: true,
+				})
+			}
+			var own []goField
+			for _, f := range g.structFields(t) {
+				if base == nil || !base.keys[f.Key] {
+					own = append(own, f)
+				}
+			}
+			rt = reflect.StructOf(append(fields, g.reflectFields(own, structs)...))
+			structs[t.Name] = rt
+		}
+	case Array:
+		return reflect.SliceOf(g.reflectType(t.Elem, structs))
+	case Map:
+		return reflect.MapOf(reflect.TypeOf(""), g.reflectType(t.Elem, structs))
+	default:
+		if t.Format == "json" || Conflicting(t) && g.opts.Mixed != MixedAny {
+			return rawType
+		}
+		return interfaceType
+	}
+	if t.Nullable {
+		rt = reflect.PtrTo(rt)
+	}
+	return rt
+}
+
+// reflectFields builds the struct fields declaring fields.
+func (g *goWriter) reflectFields(fields []goField, structs map[string]reflect.Type) []reflect.StructField {
+	var list []reflect.StructField
+	for _, f := range fields {
+		// encoding/json ignores unexported fields, so leave them out and let
+		// the diff report what they would have held
+		if !token.IsExported(f.Name) {
+			continue
+		}
+		list = append(list, reflect.StructField{
+			Name: f.Name,
+			Type: g.reflectType(f.Type, structs),
+			Tag:  reflect.StructTag(g.tag(f)),
+		})
+	}
+	return list
+}
+
+type differ struct {
+	warnings []Warning
+	dropped  int
+	omitted  map[string]bool // paths overrides left out, not reported
+}
+
+func (d *differ) add(path, msg string) {
+	if len(d.warnings) >= maxWarnings {
+		d.dropped++
+		return
+	}
+	d.warnings = append(d.warnings, Warning{path, msg})
+}
+
+// isOmitted reports whether overrides left out the field at path, which may
+// name a sample.
+func (d *differ) isOmitted(path string) bool {
+	if strings.HasPrefix(path, "$<sample ") {
+		path = "$" + path[strings.Index(path, ">")+1:]
+	}
+	return d.omitted[normalPath(path)]
+}
+
+// diff compares the decoded input a with the re-encoded b.
+func (d *differ) diff(path string, a, b interface{}) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		bm, ok := b.(map[string]interface{})
+		if !ok {
+			d.add(path, fmt.Sprintf("object became %s", describe(b)))
+			return
+		}
+		keys := make([]string, 0, len(a))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := childPath(path, k)
+			bv, ok := bm[k]
+			if !ok && d.isOmitted(p) {
+				continue
+			}
+			if !ok {
+				d.add(p, fmt.Sprintf("lost %s", describe(a[k])))
+				continue
+			}
+			d.diff(p, a[k], bv)
+		}
+	case []interface{}:
+		bs, ok := b.([]interface{})
+		if !ok {
+			d.add(path, fmt.Sprintf("array became %s", describe(b)))
+			return
+		}
+		if len(a) != len(bs) {
+			d.add(path, fmt.Sprintf("array of %d elements became %d elements", len(a), len(bs)))
+			return
+		}
+		for i := range a {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), a[i], bs[i])
+		}
+	default:
+		if !sameValue(a, b) {
+			d.add(path, fmt.Sprintf("%s became %s", describe(a), describe(b)))
+		}
+	}
+}
+
+// sameValue reports whether the JSON values a and b are equal, comparing
+// numbers by value so 1.0 and 1 are the same.
+func sameValue(a, b interface{}) bool {
+	x, ok := parseNumber(a)
+	y, ok2 := parseNumber(b)
+	if !ok || !ok2 {
+		return a == b
+	}
+	fx, _ := new(big.Float).SetPrec(numberPrec).SetString(x.text)
+	fy, _ := new(big.Float).SetPrec(numberPrec).SetString(y.text)
+	return fx != nil && fy != nil && fx.Cmp(fy) == 0
+}
+
+// describe shortens v for a warning message.
+func describe(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	b, _ := json.Marshal(v)
+	if len(b) > 40 {
+		return string(b[:37]) + "..."
+	}
+	return string(b)
+}
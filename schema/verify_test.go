@@ -0,0 +1,48 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "fixture.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := decode(t, string(data))
+	if warnings := Verify(Infer("Customer", samples...), GoOptions{}, samples...); len(warnings) > 0 {
+		t.Errorf("the fixture does not round-trip: %v", warnings)
+	}
+}
+
+func TestVerifyDifferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		typed   string // the samples the types are inferred from
+		checked string // the samples verified against them
+		want    []string
+	}{
+		{"same", `{"a": 1}`, `{"a": 2}`, nil},
+		{"unknown key", `{"a": 1}`, `{"a": 1, "b": "x"}`, []string{`$.b: lost "x"`}},
+		{"timestamp spelling", `{"t": "2020-01-02T03:04:05.000Z"}`, `{"t": "2020-01-02T03:04:05.000Z"}`,
+			[]string{`$.t: "2020-01-02T03:04:05.000Z" became "2020-01-02T03:04:05Z"`}},
+		{"wrong type", `{"a": 1}`, `{"a": "x"}`, []string{
+			`$: does not decode: json: cannot unmarshal string into Go struct field .a of type int64`,
+			`$.a: "x" became 0`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, w := range Verify(Infer("Root", decode(t, tt.typed)...), GoOptions{}, decode(t, tt.checked)...) {
+				got = append(got, w.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}